@@ -23,9 +23,13 @@ import (
 	"net"
 	"net/http"
 	"os"
+	"strings"
+	"time"
 
 	proto "github.com/container-storage-interface/spec/lib/go/csi"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 
 	"github.com/sergelogvinov/hybrid-csi-plugin/pkg/csi"
 	"github.com/sergelogvinov/hybrid-csi-plugin/pkg/tools"
@@ -44,6 +48,16 @@ var (
 
 	nodeID = flag.String("node-id", "", "Node name")
 
+	topologyKeys         = flag.String("topology-keys", envOrDefault("TOPOLOGY_KEYS", strings.Join(csi.DefaultTopologyKeys, ",")), "Comma separated list of node labels registered as topology segments.")
+	topologyMissingKey   = flag.String("topology-missing-key-policy", envOrDefault("TOPOLOGY_MISSING_KEY_POLICY", csi.TopologyMissingKeyFail), "What to do if a topology label is missing on the node: omit, default or fail.")
+	topologyDefaultValue = flag.String("topology-default-value", os.Getenv("TOPOLOGY_DEFAULT_VALUE"), "The value of the missing topology labels with the default missing key policy.")
+
+	volumeLimitPolicy   = flag.String("max-volumes-policy", envOrDefault("MAX_VOLUMES_POLICY", csi.VolumeLimitSum), "How the volume limits of the backend drivers are combined: sum or max.")
+	volumeLimitOverride = flag.Int64("max-volumes-per-node", 0, "The maximum number of volumes of the node, overrides the backend driver limits if set.")
+
+	backendSockets = flag.String("backend-sockets", os.Getenv("BACKEND_SOCKETS"), "Comma separated list of the backend driver CSI sockets which must exist for the plugin to be ready.")
+	healthInterval = flag.Duration("health-check-interval", 10*time.Second, "Interval of the readiness checks, reported by the CSI Probe call, the gRPC health service and the /readyz endpoint.")
+
 	metricsAddress = flag.String("metrics-address", "", "The TCP network address where the HTTP server for metrics, will listen (example: `:8080`). By default the server is disabled.")
 	metricsPath    = flag.String("metrics-path", "/metrics", "The HTTP path where prometheus metrics will be exposed.")
 
@@ -88,6 +102,27 @@ func main() {
 		}
 	}
 
+	topology := csi.TopologyConfig{
+		Keys:             splitList(*topologyKeys),
+		MissingKeyPolicy: *topologyMissingKey,
+		DefaultValue:     *topologyDefaultValue,
+	}
+
+	if err := topology.Validate(); err != nil {
+		klog.ErrorS(err, "Invalid topology configuration")
+		klog.FlushAndExit(klog.ExitFlushTimeout, 1)
+	}
+
+	limits := csi.VolumeLimitConfig{
+		Policy:   *volumeLimitPolicy,
+		Override: *volumeLimitOverride,
+	}
+
+	if err := limits.Validate(); err != nil {
+		klog.ErrorS(err, "Invalid volume limit configuration")
+		klog.FlushAndExit(klog.ExitFlushTimeout, 1)
+	}
+
 	scheme, addr, err := csi.ParseEndpoint(*csiEndpoint)
 	if err != nil {
 		klog.Error(err, "Failed to parse endpoint")
@@ -117,10 +152,15 @@ func main() {
 		grpc.UnaryInterceptor(logErr),
 	}
 
+	readiness := csi.NewReadiness(nodeName, clientset, splitList(*backendSockets))
+
 	// Prepare http endpoint for metrics
 	mux := http.NewServeMux()
 	if *metricsAddress != "" {
+		csi.RegisterMetrics()
+
 		mux.Handle("/metrics", legacyregistry.Handler())
+		readiness.RegisterHandlers(mux)
 
 		go func() {
 			klog.V(2).InfoS("Metrics listening", "address", *metricsAddress, "metricsPath", *metricsPath)
@@ -134,7 +174,7 @@ func main() {
 
 	srv := grpc.NewServer(opts...)
 
-	identityService := csi.NewIdentityService()
+	identityService := csi.NewIdentityService(readiness)
 
 	controllerService, err := csi.NewControllerService(clientset)
 	if err != nil {
@@ -142,12 +182,17 @@ func main() {
 		klog.FlushAndExit(klog.ExitFlushTimeout, 1)
 	}
 
-	nodeService := csi.NewNodeService(nodeName, clientset)
+	nodeService := csi.NewNodeService(nodeName, clientset, topology, limits)
 
 	proto.RegisterIdentityServer(srv, identityService)
 	proto.RegisterControllerServer(srv, controllerService)
 	proto.RegisterNodeServer(srv, nodeService)
 
+	healthServer := health.NewServer()
+	healthpb.RegisterHealthServer(srv, healthServer)
+
+	go readiness.RunHealthServer(ctx, healthServer, *healthInterval)
+
 	klog.InfoS("Listening for connection on address", "address", listener.Addr())
 
 	if err := srv.Serve(listener); err != nil {
@@ -155,3 +200,23 @@ func main() {
 		klog.FlushAndExit(klog.ExitFlushTimeout, 1)
 	}
 }
+
+func splitList(s string) []string {
+	var res []string
+
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			res = append(res, v)
+		}
+	}
+
+	return res
+}
+
+func envOrDefault(name, value string) string {
+	if v := os.Getenv(name); v != "" {
+		return v
+	}
+
+	return value
+}
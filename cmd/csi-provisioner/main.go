@@ -34,12 +34,19 @@ import (
 	controller "sigs.k8s.io/sig-storage-lib-external-provisioner/v10/controller"
 	libmetrics "sigs.k8s.io/sig-storage-lib-external-provisioner/v10/controller/metrics"
 
+	hybridv1alpha1 "github.com/sergelogvinov/hybrid-csi-plugin/pkg/apis/hybrid/v1alpha1"
+	hybridconfig "github.com/sergelogvinov/hybrid-csi-plugin/pkg/config"
+	"github.com/sergelogvinov/hybrid-csi-plugin/pkg/extender"
 	"github.com/sergelogvinov/hybrid-csi-plugin/pkg/provisioner"
 
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/dynamicinformer"
 	"k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
+	storagelistersv1 "k8s.io/client-go/listers/storage/v1"
 	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/clientcmd"
 	"k8s.io/component-base/metrics/legacyregistry"
 	"k8s.io/klog/v2"
@@ -66,6 +73,20 @@ var (
 	leaderElectionRetryPeriod   = flag.Duration("leader-election-retry-period", 5*time.Second, "Duration, in seconds, the LeaderElector clients should wait between tries of actions. Defaults to 5 seconds.")
 
 	method = flag.String("method", "auto", "PV provisioner method. Can be 'auto', 'pod' or 'annotation'.")
+
+	extenderEndpoint = flag.String("scheduler-extender-endpoint", "", "The TCP network address where the kube-scheduler extender will listen (example: `:8081`). The default is empty string, which means the extender is disabled.")
+
+	enableCapacity       = flag.Bool("enable-capacity", false, "Publish CSIStorageCapacity objects of the hybrid storage classes, aggregated from the capacities of the backend storage classes.")
+	capacityNamespace    = flag.String("capacity-namespace", "", "Namespace of the published CSIStorageCapacity objects. Defaults to the POD_NAMESPACE environment variable.")
+	capacityPollInterval = flag.Duration("capacity-poll-interval", time.Minute, "Interval between the updates of the published CSIStorageCapacity objects.")
+
+	backendNodeLabels = flag.Bool("backend-node-labels", false, "Keep the backend.csi.hybrid.sinextra.dev/<driver> node labels in sync with the CSI drivers registered on the nodes.")
+
+	configFile           = flag.String("config", "", "Path to the YAML configuration file. The file is checked for changes every --config-reload-interval, an invalid file is reported and the previous configuration is kept.")
+	configReloadInterval = flag.Duration("config-reload-interval", 30*time.Second, "Interval between the checks of the configuration file for changes.")
+
+	volumePlacement     = flag.Bool("volume-placement", false, "Record each provisioning decision in a VolumePlacement resource. The VolumePlacement CRD must be installed.")
+	hybridStoragePolicy = flag.Bool("hybrid-storage-policy", false, "Enable HybridStoragePolicy resources referenced by the policy parameter of the StorageClass. The HybridStoragePolicy CRD must be installed.")
 )
 
 const (
@@ -104,6 +125,12 @@ func main() {
 		os.Exit(1)
 	}
 
+	cfg, err := hybridconfig.NewStore(*configFile, provisioner.ValidateConfig)
+	if err != nil {
+		klog.ErrorS(err, "Failed to load configuration", "path", *configFile)
+		klog.FlushAndExit(klog.ExitFlushTimeout, 1)
+	}
+
 	// get the KUBECONFIG from env if specified (useful for local/debug cluster)
 	kubeconfigEnv := os.Getenv("KUBECONFIG")
 
@@ -139,6 +166,12 @@ func main() {
 		klog.FlushAndExit(klog.ExitFlushTimeout, 1)
 	}
 
+	dynamicClient, err := dynamic.NewForConfig(config)
+	if err != nil {
+		klog.ErrorS(err, "Failed to create a dynamic client")
+		klog.FlushAndExit(klog.ExitFlushTimeout, 1)
+	}
+
 	// Generate a unique ID for this provisioner
 	timeStamp := time.Now().UnixNano() / int64(time.Millisecond)
 	identity := strconv.FormatInt(timeStamp, 10) + "-" + strconv.Itoa(rand.Intn(10000)) + "-" + DriverName
@@ -149,7 +182,39 @@ func main() {
 	scLister := factory.Storage().V1().StorageClasses().Lister()
 	claimLister := factory.Core().V1().PersistentVolumeClaims().Lister()
 	csiNodeLister := factory.Storage().V1().CSINodes().Lister()
+	vaInformer := factory.Storage().V1().VolumeAttachments().Informer()
 	nodeLister := factory.Core().V1().Nodes().Lister()
+	pvLister := factory.Core().V1().PersistentVolumes().Lister()
+
+	if err := provisioner.AddVolumeAttachmentIndexers(vaInformer); err != nil {
+		klog.ErrorS(err, "Failed to add volumeattachment indexers")
+		klog.FlushAndExit(klog.ExitFlushTimeout, 1)
+	}
+
+	var capacityLister storagelistersv1.CSIStorageCapacityLister
+	if *enableCapacity {
+		capacityLister = factory.Storage().V1().CSIStorageCapacities().Lister()
+
+		if *capacityNamespace == "" {
+			*capacityNamespace = os.Getenv("POD_NAMESPACE")
+		}
+
+		if *capacityNamespace == "" {
+			klog.Fatalf("Namespace of CSIStorageCapacity objects is required, use --capacity-namespace or POD_NAMESPACE environment variable")
+		}
+	}
+
+	dynamicFactory := dynamicinformer.NewDynamicSharedInformerFactory(dynamicClient, ResyncPeriodOfCsiNodeInformer)
+
+	var policyLister cache.GenericLister
+	if *hybridStoragePolicy {
+		policyLister = dynamicFactory.ForResource(hybridv1alpha1.HybridStoragePolicyResource).Lister()
+	}
+
+	var placementClient dynamic.Interface
+	if *volumePlacement {
+		placementClient = dynamicClient
+	}
 
 	// claimInformer := factory.Core().V1().PersistentVolumeClaims().Informer()
 	// volumeInformer := factory.Core().V1().PersistentVolumes().Informer()
@@ -167,7 +232,64 @@ func main() {
 		// controller.VolumesInformer(volumeInformer),
 	}
 
-	csiProvisioner := provisioner.NewProvisioner(ctx, clientset, *method, driverLister, scLister, csiNodeLister, nodeLister, claimLister)
+	csiProvisioner := provisioner.NewProvisioner(ctx, clientset, placementClient, *method, cfg, driverLister, scLister, csiNodeLister, vaInformer.GetIndexer(), nodeLister, claimLister, pvLister, policyLister)
+
+	if err := csiProvisioner.AddClaimHandlers(factory.Core().V1().PersistentVolumeClaims().Informer()); err != nil {
+		klog.ErrorS(err, "Failed to add claim event handlers")
+		klog.FlushAndExit(klog.ExitFlushTimeout, 1)
+	}
+
+	if *extenderEndpoint != "" {
+		// The extender is served by all replicas, so it has its own informers started regardless of the leader election.
+		// The event handlers of the provisioner are registered on the shared informers, they run on the leader only.
+		extenderFactory := informers.NewSharedInformerFactory(clientset, ResyncPeriodOfCsiNodeInformer)
+		extenderDynamicFactory := dynamicinformer.NewDynamicSharedInformerFactory(dynamicClient, ResyncPeriodOfCsiNodeInformer)
+
+		extenderSCLister := extenderFactory.Storage().V1().StorageClasses().Lister()
+		extenderClaimLister := extenderFactory.Core().V1().PersistentVolumeClaims().Lister()
+		extenderNodeLister := extenderFactory.Core().V1().Nodes().Lister()
+		extenderVAInformer := extenderFactory.Storage().V1().VolumeAttachments().Informer()
+
+		if err := provisioner.AddVolumeAttachmentIndexers(extenderVAInformer); err != nil {
+			klog.ErrorS(err, "Failed to add volumeattachment indexers")
+			klog.FlushAndExit(klog.ExitFlushTimeout, 1)
+		}
+
+		var extenderPolicyLister cache.GenericLister
+		if *hybridStoragePolicy {
+			extenderPolicyLister = extenderDynamicFactory.ForResource(hybridv1alpha1.HybridStoragePolicyResource).Lister()
+		}
+
+		// The attachments and the volumes are used by the attach limit and the sticky policy checks.
+		evaluator := provisioner.NewProvisioner(ctx, clientset, nil, *method, cfg,
+			extenderFactory.Storage().V1().CSIDrivers().Lister(),
+			extenderSCLister,
+			extenderFactory.Storage().V1().CSINodes().Lister(),
+			extenderVAInformer.GetIndexer(),
+			extenderNodeLister,
+			extenderClaimLister,
+			extenderFactory.Core().V1().PersistentVolumes().Lister(),
+			extenderPolicyLister,
+		)
+
+		extenderFactory.Start(ctx.Done())
+		extenderDynamicFactory.Start(ctx.Done())
+
+		extenderFactory.WaitForCacheSync(ctx.Done())
+		extenderDynamicFactory.WaitForCacheSync(ctx.Done())
+
+		extenderMux := http.NewServeMux()
+		extender.NewExtender(evaluator, extenderSCLister, extenderClaimLister, extenderNodeLister).RegisterHandlers(extenderMux)
+
+		go func() {
+			klog.Infof("Scheduler extender listening at %q", *extenderEndpoint)
+
+			err := http.ListenAndServe(*extenderEndpoint, extenderMux)
+			if err != nil {
+				klog.Fatalf("Failed to start scheduler extender at specified address (%q): %s", *extenderEndpoint, err)
+			}
+		}()
+	}
 
 	// Prepare http endpoint for metrics + leader election healthz
 	mux := http.NewServeMux()
@@ -192,6 +314,7 @@ func main() {
 			m.PersistentVolumeDeleteFailedTotal,
 			m.PersistentVolumeDeleteDurationSeconds,
 		}...)
+		reg.MustRegister(provisioner.Collectors()...)
 		provisionerOptions = append(provisionerOptions, controller.MetricsInstance(m))
 		gatherers = append(gatherers, reg)
 
@@ -220,8 +343,16 @@ func main() {
 
 	klog.InfoS("Starting the CSI Provisioner")
 
+	go cfg.Run(ctx, *configReloadInterval)
+
 	run := func(ctx context.Context) {
+		// The claims are requeued by the leader only, the followers would patch the same claims.
+		if err := csiProvisioner.AddRequeueHandlers(ctx, factory.Storage().V1().CSINodes().Informer(), factory.Storage().V1().StorageClasses().Informer()); err != nil {
+			klog.Fatalf("Failed to add requeue event handlers: %v", err)
+		}
+
 		factory.Start(ctx.Done())
+		dynamicFactory.Start(ctx.Done())
 
 		cacheSyncResult := factory.WaitForCacheSync(ctx.Done())
 		for _, v := range cacheSyncResult {
@@ -230,6 +361,23 @@ func main() {
 			}
 		}
 
+		for _, v := range dynamicFactory.WaitForCacheSync(ctx.Done()) {
+			if !v {
+				klog.Fatalf("Failed to sync dynamic Informers!")
+			}
+		}
+
+		go csiProvisioner.RunGarbageCollector(ctx)
+		go csiProvisioner.RunCanary(ctx)
+
+		if capacityLister != nil {
+			go csiProvisioner.RunCapacityPublisher(ctx, capacityLister, *capacityNamespace, *capacityPollInterval)
+		}
+
+		if *backendNodeLabels {
+			go csiProvisioner.RunBackendNodeLabels(ctx, factory.Storage().V1().CSINodes().Informer())
+		}
+
 		provisionController.Run(ctx)
 	}
 
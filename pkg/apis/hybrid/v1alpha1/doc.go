@@ -0,0 +1,34 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package v1alpha1 contains the v1alpha1 API of the hybrid.sinextra.dev group
+package v1alpha1
+
+import (
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// GroupName is the API group name
+const GroupName = "hybrid.sinextra.dev"
+
+// SchemeGroupVersion is the API group version
+var SchemeGroupVersion = schema.GroupVersion{Group: GroupName, Version: "v1alpha1"}
+
+// VolumePlacementResource is the VolumePlacement resource
+var VolumePlacementResource = SchemeGroupVersion.WithResource("volumeplacements")
+
+// HybridStoragePolicyResource is the HybridStoragePolicy resource
+var HybridStoragePolicyResource = SchemeGroupVersion.WithResource("hybridstoragepolicies")
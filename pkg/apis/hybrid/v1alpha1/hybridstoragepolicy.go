@@ -0,0 +1,72 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// HybridStoragePolicy is the provisioning policy of the hybrid StorageClass,
+// referenced by the policy parameter of the StorageClass
+type HybridStoragePolicy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec HybridStoragePolicySpec `json:"spec"`
+}
+
+// HybridStoragePolicySpec is the spec of the HybridStoragePolicy
+type HybridStoragePolicySpec struct {
+	// Backends is the list of backend StorageClasses, in order of priority
+	Backends []HybridStoragePolicyBackend `json:"backends"`
+	// SpreadPolicy defines how the volumes of the same workload are distributed across the backends
+	SpreadPolicy string `json:"spreadPolicy,omitempty"`
+	// ConsistencyPolicy defines whether the volumes of the same workload must use the same backend
+	ConsistencyPolicy string `json:"consistencyPolicy,omitempty"`
+	// MountOptionsPolicy defines how the mount options of the hybrid and backend StorageClasses are merged
+	MountOptionsPolicy string `json:"mountOptionsPolicy,omitempty"`
+	// Failover defines what to do when the backend fails
+	Failover *HybridStoragePolicyFailover `json:"failover,omitempty"`
+}
+
+// HybridStoragePolicyBackend is a backend StorageClass with its capabilities
+type HybridStoragePolicyBackend struct {
+	// StorageClassName is the name of the backend StorageClass
+	StorageClassName string `json:"storageClassName"`
+	// NodeSelector restricts the backend to the matching nodes
+	NodeSelector *metav1.LabelSelector `json:"nodeSelector,omitempty"`
+	// Features is the list of features the backend provides
+	Features []string `json:"features,omitempty"`
+	// AccessModes is the list of supported access modes, all modes if empty
+	AccessModes []corev1.PersistentVolumeAccessMode `json:"accessModes,omitempty"`
+	// VolumeModes is the list of supported volume modes, all modes if empty
+	VolumeModes []corev1.PersistentVolumeMode `json:"volumeModes,omitempty"`
+	// MinSize is the minimum volume size
+	MinSize *resource.Quantity `json:"minSize,omitempty"`
+	// MaxSize is the maximum volume size
+	MaxSize *resource.Quantity `json:"maxSize,omitempty"`
+	// SizeGranularity is the allocation unit of the backend
+	SizeGranularity *resource.Quantity `json:"sizeGranularity,omitempty"`
+}
+
+// HybridStoragePolicyFailover defines the failover settings
+type HybridStoragePolicyFailover struct {
+	// NodeAffinityMismatch defines what to do if the backend volume is not accessible from the selected node
+	NodeAffinityMismatch string `json:"nodeAffinityMismatch,omitempty"`
+}
@@ -0,0 +1,90 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// VolumePlacementPhase is the phase of the hybrid provisioning
+type VolumePlacementPhase string
+
+const (
+	// VolumePlacementSelecting means the backend StorageClass is being selected
+	VolumePlacementSelecting VolumePlacementPhase = "Selecting"
+	// VolumePlacementBinding means the backend volume is being provisioned and bound
+	VolumePlacementBinding VolumePlacementPhase = "Binding"
+	// VolumePlacementReleasing means the backend volume is being released from the provisioning claim
+	VolumePlacementReleasing VolumePlacementPhase = "Releasing"
+	// VolumePlacementBonded means the backend volume is bound to the user claim
+	VolumePlacementBonded VolumePlacementPhase = "Bonded"
+	// VolumePlacementFailed means the provisioning attempt failed
+	VolumePlacementFailed VolumePlacementPhase = "Failed"
+)
+
+// VolumePlacement records the hybrid provisioning decision of a PersistentVolumeClaim
+type VolumePlacement struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   VolumePlacementSpec   `json:"spec,omitempty"`
+	Status VolumePlacementStatus `json:"status,omitempty"`
+}
+
+// VolumePlacementSpec is the spec of the VolumePlacement
+type VolumePlacementSpec struct {
+	// ClaimName is the name of the PersistentVolumeClaim
+	ClaimName string `json:"claimName"`
+	// StorageClassName is the name of the hybrid StorageClass
+	StorageClassName string `json:"storageClassName"`
+}
+
+// VolumePlacementStatus is the status of the VolumePlacement
+type VolumePlacementStatus struct {
+	// Phase is the current phase of the provisioning
+	Phase VolumePlacementPhase `json:"phase,omitempty"`
+	// Message is the details of the last failure
+	Message string `json:"message,omitempty"`
+	// SelectedNode is the node selected by the scheduler
+	SelectedNode string `json:"selectedNode,omitempty"`
+	// BackendStorageClassName is the name of the selected backend StorageClass
+	BackendStorageClassName string `json:"backendStorageClassName,omitempty"`
+	// Method is the provisioning method
+	Method string `json:"method,omitempty"`
+	// VolumeName is the name of the provisioned PersistentVolume
+	VolumeName string `json:"volumeName,omitempty"`
+	// RejectedCandidates is the list of rejected backend StorageClasses
+	RejectedCandidates []VolumePlacementCandidate `json:"rejectedCandidates,omitempty"`
+	// Timings is the list of the phase transitions
+	Timings []VolumePlacementTiming `json:"timings,omitempty"`
+}
+
+// VolumePlacementCandidate is a rejected backend StorageClass
+type VolumePlacementCandidate struct {
+	// Name is the name of the backend StorageClass
+	Name string `json:"name"`
+	// Reason is the reason of the rejection
+	Reason string `json:"reason,omitempty"`
+}
+
+// VolumePlacementTiming is a phase transition
+type VolumePlacementTiming struct {
+	// Phase is the phase
+	Phase VolumePlacementPhase `json:"phase"`
+	// Time is the time of the transition
+	Time metav1.Time `json:"time"`
+}
@@ -0,0 +1,245 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"sigs.k8s.io/yaml"
+)
+
+// Config is the configuration of the hybrid provisioner
+type Config struct {
+	// Timeouts of the provisioning steps
+	Timeouts Timeouts `json:"timeouts"`
+	// Backoff of the retried API calls
+	Backoff Backoff `json:"backoff"`
+	// HelperPod is the pod used by the pod provisioning method
+	HelperPod HelperPod `json:"helperPod"`
+	// Concurrency limits of the provisioning
+	Concurrency Concurrency `json:"concurrency"`
+	// CircuitBreaker skips the failing backends
+	CircuitBreaker CircuitBreaker `json:"circuitBreaker"`
+	// GarbageCollection of the leftovers of failed provisioning
+	GarbageCollection GarbageCollection `json:"garbageCollection"`
+	// Canary provisioning of the backends
+	Canary Canary `json:"canary"`
+	// DefaultParameters are used for the parameters missing in the hybrid StorageClasses
+	DefaultParameters map[string]string `json:"defaultParameters,omitempty"`
+}
+
+// Timeouts of the provisioning steps
+type Timeouts struct {
+	// Bind is the time to wait for the backend to bind the volume
+	Bind metav1.Duration `json:"bind"`
+	// Delete is the time to wait for the backend to delete the volume
+	Delete metav1.Duration `json:"delete"`
+}
+
+// Backoff of the retried API calls
+type Backoff struct {
+	Duration metav1.Duration `json:"duration"`
+	Factor   float64         `json:"factor"`
+	Steps    int             `json:"steps"`
+}
+
+// HelperPod is the pod used by the pod provisioning method
+type HelperPod struct {
+	Image             string                      `json:"image"`
+	Resources         corev1.ResourceRequirements `json:"resources"`
+	Tolerations       []corev1.Toleration         `json:"tolerations,omitempty"`
+	PriorityClassName string                      `json:"priorityClassName,omitempty"`
+}
+
+// Concurrency limits of the provisioning
+type Concurrency struct {
+	// MaxInFlight is the limit of concurrent in-flight provisions per backend StorageClass, 0 means no limit
+	MaxInFlight int `json:"maxInFlight"`
+}
+
+// CircuitBreaker skips the failing backends
+type CircuitBreaker struct {
+	// FailureThreshold is the number of consecutive failures after which the backend is skipped, 0 disables the circuit breaker
+	FailureThreshold int `json:"failureThreshold"`
+	// OpenDuration is the time the backend is skipped
+	OpenDuration metav1.Duration `json:"openDuration"`
+}
+
+// GarbageCollection of the leftovers of failed provisioning
+type GarbageCollection struct {
+	Enabled bool `json:"enabled"`
+	// Interval between the collections
+	Interval metav1.Duration `json:"interval"`
+	// MaxAge is the age of the helper objects after which they are deleted
+	MaxAge metav1.Duration `json:"maxAge"`
+}
+
+// Canary provisioning of the backends
+type Canary struct {
+	Enabled bool `json:"enabled"`
+	// Interval between the canary runs
+	Interval metav1.Duration `json:"interval"`
+	// Namespace of the canary objects
+	Namespace string `json:"namespace"`
+	// Size of the canary volume, raised to the minimum size of the backend
+	Size resource.Quantity `json:"size"`
+	// TopologyKey is the node label which defines the topology segments, a canary runs on one node of each segment
+	TopologyKey string `json:"topologyKey"`
+}
+
+// Default returns the default configuration
+func Default() *Config {
+	return &Config{
+		Timeouts: Timeouts{
+			Bind:   metav1.Duration{Duration: 30 * time.Second},
+			Delete: metav1.Duration{Duration: 10 * time.Minute},
+		},
+		Backoff: Backoff{
+			Duration: metav1.Duration{Duration: 10 * time.Second},
+			Factor:   1, // linear backoff
+			Steps:    5,
+		},
+		HelperPod: HelperPod{
+			Image: "registry.k8s.io/pause:3.10",
+			Resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{
+					corev1.ResourceCPU:    resource.MustParse("10m"),
+					corev1.ResourceMemory: resource.MustParse("10Mi"),
+				},
+				Limits: corev1.ResourceList{
+					corev1.ResourceCPU:    resource.MustParse("10m"),
+					corev1.ResourceMemory: resource.MustParse("10Mi"),
+				},
+			},
+			Tolerations: []corev1.Toleration{
+				{Operator: corev1.TolerationOpExists},
+			},
+		},
+		CircuitBreaker: CircuitBreaker{
+			FailureThreshold: 3,
+			OpenDuration:     metav1.Duration{Duration: 2 * time.Minute},
+		},
+		GarbageCollection: GarbageCollection{
+			Enabled:  false,
+			Interval: metav1.Duration{Duration: 10 * time.Minute},
+			MaxAge:   metav1.Duration{Duration: time.Hour},
+		},
+		Canary: Canary{
+			Enabled:     false,
+			Interval:    metav1.Duration{Duration: 15 * time.Minute},
+			Namespace:   metav1.NamespaceSystem,
+			Size:        resource.MustParse("1Gi"),
+			TopologyKey: corev1.LabelTopologyZone,
+		},
+	}
+}
+
+// Parse parses the YAML configuration, the missing fields are set to their defaults
+func Parse(data []byte) (*Config, error) {
+	cfg := Default()
+
+	if err := yaml.UnmarshalStrict(data, cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse config: %v", err)
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+// Validate checks the configuration values
+func (c *Config) Validate() error {
+	if c.Timeouts.Bind.Duration <= 0 {
+		return fmt.Errorf("timeouts.bind must be positive")
+	}
+
+	if c.Timeouts.Delete.Duration <= 0 {
+		return fmt.Errorf("timeouts.delete must be positive")
+	}
+
+	if c.Backoff.Duration.Duration <= 0 {
+		return fmt.Errorf("backoff.duration must be positive")
+	}
+
+	if c.Backoff.Factor < 1 {
+		return fmt.Errorf("backoff.factor must be at least 1")
+	}
+
+	if c.Backoff.Steps < 1 {
+		return fmt.Errorf("backoff.steps must be at least 1")
+	}
+
+	if c.Concurrency.MaxInFlight < 0 {
+		return fmt.Errorf("concurrency.maxInFlight must not be negative")
+	}
+
+	if c.CircuitBreaker.FailureThreshold < 0 {
+		return fmt.Errorf("circuitBreaker.failureThreshold must not be negative")
+	}
+
+	if c.CircuitBreaker.OpenDuration.Duration <= 0 {
+		return fmt.Errorf("circuitBreaker.openDuration must be positive")
+	}
+
+	if c.HelperPod.Image == "" {
+		return fmt.Errorf("helperPod.image is required")
+	}
+
+	if c.GarbageCollection.Interval.Duration <= 0 {
+		return fmt.Errorf("garbageCollection.interval must be positive")
+	}
+
+	if c.GarbageCollection.MaxAge.Duration <= 0 {
+		return fmt.Errorf("garbageCollection.maxAge must be positive")
+	}
+
+	if c.Canary.Interval.Duration <= 0 {
+		return fmt.Errorf("canary.interval must be positive")
+	}
+
+	if c.Canary.Enabled {
+		if c.Canary.Namespace == "" {
+			return fmt.Errorf("canary.namespace is required")
+		}
+
+		if c.Canary.Size.Sign() <= 0 {
+			return fmt.Errorf("canary.size must be positive")
+		}
+
+		if c.Canary.TopologyKey == "" {
+			return fmt.Errorf("canary.topologyKey is required")
+		}
+	}
+
+	return nil
+}
+
+// WaitBackoff returns the backoff of the retried API calls
+func (b Backoff) WaitBackoff() wait.Backoff {
+	return wait.Backoff{
+		Duration: b.Duration.Duration,
+		Factor:   b.Factor,
+		Steps:    b.Steps,
+	}
+}
@@ -0,0 +1,129 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		check   func(*Config) bool
+		wantErr string
+	}{
+		{
+			name: "empty config uses the defaults",
+			data: "",
+			check: func(cfg *Config) bool {
+				return cfg.Timeouts.Bind.Duration == 30*time.Second && cfg.Backoff.Steps == 5
+			},
+		},
+		{
+			name: "set fields override the defaults",
+			data: "timeouts:\n  bind: 1m\nbackoff:\n  steps: 3\nconcurrency:\n  maxInFlight: 2\n",
+			check: func(cfg *Config) bool {
+				return cfg.Timeouts.Bind.Duration == time.Minute &&
+					cfg.Timeouts.Delete.Duration == 10*time.Minute &&
+					cfg.Backoff.Steps == 3 && cfg.Backoff.Factor == 1 &&
+					cfg.Concurrency.MaxInFlight == 2
+			},
+		},
+		{
+			name:    "unknown field",
+			data:    "timeout:\n  bind: 1m\n",
+			wantErr: "failed to parse config",
+		},
+		{
+			name:    "invalid duration",
+			data:    "timeouts:\n  bind: soon\n",
+			wantErr: "failed to parse config",
+		},
+		{
+			name:    "negative bind timeout",
+			data:    "timeouts:\n  bind: -1s\n",
+			wantErr: "timeouts.bind must be positive",
+		},
+		{
+			name:    "zero delete timeout",
+			data:    "timeouts:\n  delete: 0s\n",
+			wantErr: "timeouts.delete must be positive",
+		},
+		{
+			name:    "backoff factor below one",
+			data:    "backoff:\n  factor: 0.5\n",
+			wantErr: "backoff.factor must be at least 1",
+		},
+		{
+			name:    "negative concurrency",
+			data:    "concurrency:\n  maxInFlight: -1\n",
+			wantErr: "concurrency.maxInFlight must not be negative",
+		},
+		{
+			name:    "zero garbage collection age",
+			data:    "garbageCollection:\n  maxAge: 0s\n",
+			wantErr: "garbageCollection.maxAge must be positive",
+		},
+		{
+			name:    "zero canary interval",
+			data:    "canary:\n  interval: 0s\n",
+			wantErr: "canary.interval must be positive",
+		},
+		{
+			name:    "enabled canary without namespace",
+			data:    "canary:\n  enabled: true\n  namespace: \"\"\n",
+			wantErr: "canary.namespace is required",
+		},
+		{
+			name: "disabled canary without namespace",
+			data: "canary:\n  namespace: \"\"\n",
+			check: func(cfg *Config) bool {
+				return !cfg.Canary.Enabled && cfg.Canary.Namespace == ""
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cfg, err := Parse([]byte(test.data))
+			if test.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+					t.Fatalf("expected error %q, got %v", test.wantErr, err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if !test.check(cfg) {
+				t.Errorf("unexpected config: %+v", cfg)
+			}
+		})
+	}
+}
+
+func TestDefaultIsValid(t *testing.T) {
+	if err := Default().Validate(); err != nil {
+		t.Errorf("default config is invalid: %v", err)
+	}
+}
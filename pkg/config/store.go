@@ -0,0 +1,113 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog/v2"
+)
+
+// Store holds the current configuration and reloads it when the file changes.
+// An invalid file is reported and the previous configuration is kept.
+type Store struct {
+	path     string
+	validate func(*Config) error
+
+	config atomic.Pointer[Config]
+	data   []byte
+}
+
+// NewStore loads the configuration file. With an empty path, the default configuration is used.
+func NewStore(path string, validate func(*Config) error) (*Store, error) {
+	s := &Store{
+		path:     path,
+		validate: validate,
+	}
+
+	s.config.Store(Default())
+
+	if path == "" {
+		return s, nil
+	}
+
+	if _, err := s.load(); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// Get returns the current configuration, it must not be modified.
+func (s *Store) Get() *Config {
+	return s.config.Load()
+}
+
+// Run checks the configuration file for changes every interval until the context is done.
+func (s *Store) Run(ctx context.Context, interval time.Duration) {
+	if s.path == "" {
+		return
+	}
+
+	wait.UntilWithContext(ctx, func(_ context.Context) {
+		changed, err := s.load()
+		if err != nil {
+			klog.ErrorS(err, "Failed to reload configuration, keeping the previous one", "path", s.path)
+
+			return
+		}
+
+		if changed {
+			klog.InfoS("Configuration reloaded", "path", s.path)
+		}
+	}, interval)
+}
+
+func (s *Store) load() (bool, error) {
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return false, fmt.Errorf("failed to read config: %v", err)
+	}
+
+	if s.data != nil && bytes.Equal(data, s.data) {
+		return false, nil
+	}
+
+	// Do not report the same invalid file on every check.
+	s.data = data
+
+	cfg, err := Parse(data)
+	if err != nil {
+		return false, err
+	}
+
+	if s.validate != nil {
+		if err := s.validate(cfg); err != nil {
+			return false, err
+		}
+	}
+
+	s.config.Store(cfg)
+
+	return true, nil
+}
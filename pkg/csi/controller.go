@@ -28,14 +28,9 @@ import (
 	"k8s.io/klog/v2"
 )
 
-var controllerCaps = []csi.ControllerServiceCapability_RPC_Type{
-	csi.ControllerServiceCapability_RPC_CREATE_DELETE_VOLUME,
-	csi.ControllerServiceCapability_RPC_PUBLISH_UNPUBLISH_VOLUME,
-	csi.ControllerServiceCapability_RPC_GET_CAPACITY,
-	csi.ControllerServiceCapability_RPC_EXPAND_VOLUME,
-	csi.ControllerServiceCapability_RPC_GET_VOLUME,
-	csi.ControllerServiceCapability_RPC_SINGLE_NODE_MULTI_WRITER,
-}
+// controllerCaps has GET_VOLUME only, the volumes are provisioned and attached by the backend drivers.
+// The list is not empty, as an empty one is sent as no list, which the CSI clients such as csi-sanity reject.
+var controllerCaps = []csi.ControllerServiceCapability_RPC_Type{csi.ControllerServiceCapability_RPC_GET_VOLUME}
 
 // ControllerService is the controller service for the CSI driver
 type ControllerService struct {
@@ -98,11 +93,23 @@ func (d *ControllerService) ControllerUnpublishVolume(_ context.Context, request
 	return nil, status.Error(codes.Unimplemented, "")
 }
 
-// ValidateVolumeCapabilities validate volume capabilities
+// ValidateVolumeCapabilities validate volume capabilities.
+// The hybrid driver does not own any volume: the provisioned PersistentVolumes carry the backend driver name
+// in spec.csi.driver, and their capabilities are validated by the backend driver. The controller cannot look up
+// the volumes, so NotFound is the only correct answer for a valid request.
 func (d *ControllerService) ValidateVolumeCapabilities(_ context.Context, request *csi.ValidateVolumeCapabilitiesRequest) (*csi.ValidateVolumeCapabilitiesResponse, error) {
 	klog.V(4).InfoS("ValidateVolumeCapabilities: called", "args", protosanitizer.StripSecrets(request))
 
-	return nil, status.Error(codes.Unimplemented, "")
+	volumeID := request.GetVolumeId()
+	if volumeID == "" {
+		return nil, status.Error(codes.InvalidArgument, "VolumeID must be provided")
+	}
+
+	if len(request.GetVolumeCapabilities()) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "VolumeCapabilities must be provided")
+	}
+
+	return nil, status.Errorf(codes.NotFound, "volume %s not found", volumeID)
 }
 
 // ListVolumes list volumes
@@ -147,11 +154,17 @@ func (d *ControllerService) ControllerExpandVolume(_ context.Context, request *c
 	return nil, status.Error(codes.Unimplemented, "")
 }
 
-// ControllerGetVolume get a volume
+// ControllerGetVolume get a volume.
+// The controller cannot look up the volumes of the backend drivers, so every volume is not found.
 func (d *ControllerService) ControllerGetVolume(_ context.Context, request *csi.ControllerGetVolumeRequest) (*csi.ControllerGetVolumeResponse, error) {
 	klog.V(4).InfoS("ControllerGetVolume: called", "args", protosanitizer.StripSecrets(request))
 
-	return nil, status.Error(codes.Unimplemented, "")
+	volumeID := request.GetVolumeId()
+	if volumeID == "" {
+		return nil, status.Error(codes.InvalidArgument, "VolumeID must be provided")
+	}
+
+	return nil, status.Errorf(codes.NotFound, "volume %s not found", volumeID)
 }
 
 // ControllerModifyVolume modify a volume
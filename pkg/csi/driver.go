@@ -25,7 +25,8 @@ const (
 	// DriverSpecVersion CSI spec version
 	DriverSpecVersion = "1.9.0"
 
-	// MaxVolumesPerNode is the maximum number of volumes that can be attached to a node
+	// MaxVolumesPerNode is the maximum number of volumes that can be attached to a node,
+	// if none of the backend drivers reports a limit
 	MaxVolumesPerNode = 24
 
 	// DefaultVolumeSize is the default size of a volume
@@ -0,0 +1,151 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package csi
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/klog/v2"
+)
+
+const readinessTimeout = 5 * time.Second
+
+// Readiness checks whether the plugin is ready to serve requests.
+type Readiness struct {
+	nodeID         string
+	kclient        kubernetes.Interface
+	backendSockets []string
+
+	mu      sync.Mutex
+	lastErr error
+}
+
+// NewReadiness returns a new Readiness. The backend sockets are the CSI sockets of the backend drivers
+// which must exist on the node.
+func NewReadiness(nodeID string, clientSet kubernetes.Interface, backendSockets []string) *Readiness {
+	return &Readiness{
+		nodeID:         nodeID,
+		kclient:        clientSet,
+		backendSockets: backendSockets,
+		lastErr:        fmt.Errorf("readiness is not checked yet"),
+	}
+}
+
+// Check returns an error if the API server is not reachable, the node does not exist
+// or a backend socket is missing.
+func (r *Readiness) Check(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, readinessTimeout)
+	defer cancel()
+
+	if err := r.kclient.Discovery().RESTClient().Get().AbsPath("/version").Do(ctx).Error(); err != nil {
+		return fmt.Errorf("failed to reach API server: %v", err)
+	}
+
+	if _, err := r.kclient.CoreV1().Nodes().Get(ctx, r.nodeID, metav1.GetOptions{}); err != nil {
+		return fmt.Errorf("failed to get node %s: %v", r.nodeID, err)
+	}
+
+	for _, socket := range r.backendSockets {
+		info, err := os.Stat(socket)
+		if err != nil {
+			return fmt.Errorf("failed to find backend socket %s: %v", socket, err)
+		}
+
+		if info.Mode()&os.ModeSocket == 0 {
+			return fmt.Errorf("backend socket %s is not a socket", socket)
+		}
+	}
+
+	return nil
+}
+
+// LastError returns the result of the last readiness check, without calling the API server.
+func (r *Readiness) LastError() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.lastErr
+}
+
+// RunHealthServer runs the readiness checks periodically, and updates the serving status of the gRPC health server
+// and the result returned by LastError.
+func (r *Readiness) RunHealthServer(ctx context.Context, srv *health.Server, interval time.Duration) {
+	first := true
+
+	for {
+		err := r.Check(ctx)
+
+		r.mu.Lock()
+		wasReady := r.lastErr == nil
+		r.lastErr = err
+		r.mu.Unlock()
+
+		// The result is logged on the readiness changes only, the check runs on each interval.
+		switch {
+		case err != nil && (wasReady || first):
+			klog.ErrorS(err, "Plugin is not ready")
+		case err == nil && !wasReady:
+			klog.InfoS("Plugin is ready")
+		}
+
+		first = false
+
+		st := healthpb.HealthCheckResponse_SERVING
+		if err != nil {
+			st = healthpb.HealthCheckResponse_NOT_SERVING
+		}
+
+		srv.SetServingStatus("", st)
+
+		select {
+		case <-ctx.Done():
+			srv.Shutdown()
+
+			return
+		case <-time.After(interval):
+		}
+	}
+}
+
+// RegisterHandlers registers the /healthz and /readyz HTTP handlers.
+func (r *Readiness) RegisterHandlers(mux *http.ServeMux) {
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, "ok") //nolint: errcheck
+	})
+
+	mux.HandleFunc("/readyz", func(w http.ResponseWriter, _ *http.Request) {
+		if err := r.LastError(); err != nil {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		fmt.Fprint(w, "ok") //nolint: errcheck
+	})
+}
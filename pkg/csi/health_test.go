@@ -0,0 +1,69 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package csi
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/container-storage-interface/spec/lib/go/csi"
+)
+
+func TestReadinessLastError(t *testing.T) {
+	r := NewReadiness("node-1", nil, nil)
+	identity := NewIdentityService(r)
+
+	mux := http.NewServeMux()
+	r.RegisterHandlers(mux)
+
+	tests := []struct {
+		name    string
+		lastErr error
+		ready   bool
+	}{
+		{name: "not checked yet", lastErr: r.LastError(), ready: false},
+		{name: "ready", lastErr: nil, ready: true},
+		{name: "not ready", lastErr: fmt.Errorf("failed to reach API server"), ready: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r.mu.Lock()
+			r.lastErr = tt.lastErr
+			r.mu.Unlock()
+
+			resp, err := identity.Probe(context.Background(), &csi.ProbeRequest{})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if resp.GetReady().GetValue() != tt.ready {
+				t.Errorf("Probe() ready = %v, want %v", resp.GetReady().GetValue(), tt.ready)
+			}
+
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+			if ok := rec.Code == http.StatusOK; ok != tt.ready {
+				t.Errorf("/readyz status = %d, want ready %v", rec.Code, tt.ready)
+			}
+		})
+	}
+}
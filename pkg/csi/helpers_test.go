@@ -0,0 +1,39 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package csi
+
+import (
+	storagev1 "k8s.io/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// newTestCSINode returns a CSINode with the drivers and their attach limits, a zero limit is not set.
+func newTestCSINode(name string, limits map[string]int32) *storagev1.CSINode {
+	csiNode := &storagev1.CSINode{ObjectMeta: metav1.ObjectMeta{Name: name}}
+
+	for driver, count := range limits {
+		d := storagev1.CSINodeDriver{Name: driver, NodeID: name}
+
+		if count > 0 {
+			d.Allocatable = &storagev1.VolumeNodeResources{Count: &count}
+		}
+
+		csiNode.Spec.Drivers = append(csiNode.Spec.Drivers, d)
+	}
+
+	return csiNode
+}
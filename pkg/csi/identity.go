@@ -27,12 +27,15 @@ import (
 
 // IdentityService is the identity service for the CSI driver
 type IdentityService struct {
+	readiness *Readiness
 	csi.UnimplementedIdentityServer
 }
 
 // NewIdentityService returns a new identity service
-func NewIdentityService() *IdentityService {
-	return &IdentityService{}
+func NewIdentityService(readiness *Readiness) *IdentityService {
+	return &IdentityService{
+		readiness: readiness,
+	}
 }
 
 // GetPluginInfo returns the name and version of the plugin
@@ -71,11 +74,13 @@ func (d *IdentityService) GetPluginCapabilities(context.Context, *csi.GetPluginC
 	return resp, nil
 }
 
-// Probe returns the health and readiness of the plugin
-func (d *IdentityService) Probe(_ context.Context, _ *csi.ProbeRequest) (*csi.ProbeResponse, error) {
+// Probe returns the health and readiness of the plugin, the result of the last periodic readiness check.
+// It does not call the API server, but one failed check makes the plugin not ready until the next successful check.
+// The readiness changes are logged by the periodic check.
+func (d *IdentityService) Probe(context.Context, *csi.ProbeRequest) (*csi.ProbeResponse, error) {
 	klog.V(5).InfoS("Probe: called")
 
 	return &csi.ProbeResponse{
-		Ready: &wrappers.BoolValue{Value: true},
+		Ready: &wrappers.BoolValue{Value: d.readiness.LastError() == nil},
 	}, nil
 }
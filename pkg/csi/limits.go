@@ -0,0 +1,102 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package csi
+
+import (
+	"context"
+	"fmt"
+
+	storagev1 "k8s.io/api/storage/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// VolumeLimitSum sets the volume limit to the sum of the backend limits.
+	VolumeLimitSum = "sum"
+	// VolumeLimitMax sets the volume limit to the largest backend limit.
+	VolumeLimitMax = "max"
+)
+
+// VolumeLimitConfig defines how the maximum number of volumes of the node is computed.
+type VolumeLimitConfig struct {
+	// Policy defines how the limits of the backend drivers are combined.
+	Policy string
+	// Override is the explicit limit, the backend limits are ignored if it is set.
+	Override int64
+}
+
+// Validate checks the volume limit configuration.
+func (c *VolumeLimitConfig) Validate() error {
+	switch c.Policy {
+	case VolumeLimitSum, VolumeLimitMax:
+	default:
+		return fmt.Errorf("unknown volume limit policy %q", c.Policy)
+	}
+
+	if c.Override < 0 {
+		return fmt.Errorf("volume limit override must not be negative")
+	}
+
+	return nil
+}
+
+// getCSINode returns the CSINode of the node, or nil if it does not exist yet.
+func (n *NodeService) getCSINode(ctx context.Context) (*storagev1.CSINode, error) {
+	csiNode, err := n.kclient.StorageV1().CSINodes().Get(ctx, n.nodeID, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("failed to get CSINode %s: %w", n.nodeID, err)
+	}
+
+	return csiNode, nil
+}
+
+// getMaxVolumesPerNode returns the maximum number of volumes of the node computed from the backend driver limits.
+func (n *NodeService) getMaxVolumesPerNode(csiNode *storagev1.CSINode) int64 {
+	if n.limits.Override > 0 {
+		return n.limits.Override
+	}
+
+	var limit int64
+
+	if csiNode != nil {
+		for _, driver := range csiNode.Spec.Drivers {
+			if driver.Name == DriverName || driver.Allocatable == nil || driver.Allocatable.Count == nil {
+				continue
+			}
+
+			count := int64(*driver.Allocatable.Count)
+
+			switch n.limits.Policy {
+			case VolumeLimitMax:
+				limit = max(limit, count)
+			default:
+				limit += count
+			}
+		}
+	}
+
+	if limit == 0 {
+		return MaxVolumesPerNode
+	}
+
+	return limit
+}
@@ -0,0 +1,72 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package csi
+
+import (
+	"testing"
+
+	storagev1 "k8s.io/api/storage/v1"
+)
+
+func TestGetMaxVolumesPerNode(t *testing.T) {
+	tests := []struct {
+		name    string
+		limits  VolumeLimitConfig
+		csiNode *storagev1.CSINode
+		want    int64
+	}{
+		{
+			name:   "no CSINode",
+			limits: VolumeLimitConfig{Policy: VolumeLimitSum},
+			want:   MaxVolumesPerNode,
+		},
+		{
+			name:    "sum of the backend limits",
+			limits:  VolumeLimitConfig{Policy: VolumeLimitSum},
+			csiNode: newTestCSINode("node-1", map[string]int32{"a.csi": 16, "b.csi": 8, DriverName: 100}),
+			want:    24,
+		},
+		{
+			name:    "largest backend limit",
+			limits:  VolumeLimitConfig{Policy: VolumeLimitMax},
+			csiNode: newTestCSINode("node-1", map[string]int32{"a.csi": 16, "b.csi": 8}),
+			want:    16,
+		},
+		{
+			name:    "backends without limits",
+			limits:  VolumeLimitConfig{Policy: VolumeLimitSum},
+			csiNode: newTestCSINode("node-1", map[string]int32{"a.csi": 0}),
+			want:    MaxVolumesPerNode,
+		},
+		{
+			name:    "override",
+			limits:  VolumeLimitConfig{Policy: VolumeLimitSum, Override: 50},
+			csiNode: newTestCSINode("node-1", map[string]int32{"a.csi": 16}),
+			want:    50,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n := &NodeService{nodeID: "node-1", limits: tt.limits}
+
+			if got := n.getMaxVolumesPerNode(tt.csiNode); got != tt.want {
+				t.Errorf("getMaxVolumesPerNode() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
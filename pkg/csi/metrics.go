@@ -0,0 +1,47 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package csi
+
+import (
+	"k8s.io/component-base/metrics"
+	"k8s.io/component-base/metrics/legacyregistry"
+)
+
+var topologySegments = metrics.NewGaugeVec(
+	&metrics.GaugeOpts{
+		Namespace:      "hybrid_csi",
+		Subsystem:      "node",
+		Name:           "topology_segment",
+		Help:           "Topology segments registered by the node plugin, labeled by the topology key and value.",
+		StabilityLevel: metrics.ALPHA,
+	},
+	[]string{"key", "value"},
+)
+
+// RegisterMetrics registers the metrics of the CSI driver.
+func RegisterMetrics() {
+	legacyregistry.MustRegister(topologySegments)
+}
+
+// setTopologySegments replaces the exported topology segments.
+func setTopologySegments(segments map[string]string) {
+	topologySegments.Reset()
+
+	for k, v := range segments {
+		topologySegments.WithLabelValues(k, v).Set(1)
+	}
+}
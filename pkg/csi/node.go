@@ -22,18 +22,16 @@ import (
 
 	"github.com/container-storage-interface/spec/lib/go/csi"
 	"github.com/kubernetes-csi/csi-lib-utils/protosanitizer"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
-	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/klog/v2"
 )
 
-var nodeCaps = []csi.NodeServiceCapability_RPC_Type{
-	csi.NodeServiceCapability_RPC_STAGE_UNSTAGE_VOLUME,
-	csi.NodeServiceCapability_RPC_EXPAND_VOLUME,
-	csi.NodeServiceCapability_RPC_GET_VOLUME_STATS,
-}
+// nodeCaps is empty, the volumes are staged and mounted by the backend drivers.
+var nodeCaps = []csi.NodeServiceCapability_RPC_Type{}
 
 var volumeCaps = []csi.VolumeCapability_AccessMode{
 	{
@@ -52,16 +50,20 @@ var volumeCaps = []csi.VolumeCapability_AccessMode{
 
 // NodeService is the node service for the CSI driver
 type NodeService struct {
-	nodeID  string
-	kclient kubernetes.Interface
+	nodeID   string
+	kclient  kubernetes.Interface
+	topology TopologyConfig
+	limits   VolumeLimitConfig
 	csi.UnimplementedNodeServer
 }
 
 // NewNodeService returns a new NodeService
-func NewNodeService(nodeID string, clientSet kubernetes.Interface) *NodeService {
+func NewNodeService(nodeID string, clientSet kubernetes.Interface, topology TopologyConfig, limits VolumeLimitConfig) *NodeService {
 	return &NodeService{
-		nodeID:  nodeID,
-		kclient: clientSet,
+		nodeID:   nodeID,
+		kclient:  clientSet,
+		topology: topology,
+		limits:   limits,
 	}
 }
 
@@ -69,29 +71,53 @@ func NewNodeService(nodeID string, clientSet kubernetes.Interface) *NodeService
 func (n *NodeService) NodeStageVolume(_ context.Context, request *csi.NodeStageVolumeRequest) (*csi.NodeStageVolumeResponse, error) {
 	klog.V(4).InfoS("NodeStageVolume: called", "args", protosanitizer.StripSecrets(request))
 
-	return &csi.NodeStageVolumeResponse{}, nil
+	return nil, status.Error(codes.Unimplemented, "")
 }
 
 // NodeUnstageVolume is called by the CO when a workload that was using the specified volume is being moved to a different node.
-//
-//nolint:dupl
 func (n *NodeService) NodeUnstageVolume(_ context.Context, request *csi.NodeUnstageVolumeRequest) (*csi.NodeUnstageVolumeResponse, error) {
 	klog.V(4).InfoS("NodeUnstageVolume: called", "args", protosanitizer.StripSecrets(request))
 
-	return &csi.NodeUnstageVolumeResponse{}, nil
+	return nil, status.Error(codes.Unimplemented, "")
 }
 
 // NodePublishVolume mounts the volume on the node.
+// The hybrid driver does not own any volume, the volumes are published by the backend drivers.
 func (n *NodeService) NodePublishVolume(_ context.Context, request *csi.NodePublishVolumeRequest) (*csi.NodePublishVolumeResponse, error) {
 	klog.V(4).InfoS("NodePublishVolume: called", "args", protosanitizer.StripSecrets(request))
 
-	return &csi.NodePublishVolumeResponse{}, nil
+	if request.GetVolumeId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "VolumeID must be provided")
+	}
+
+	if request.GetTargetPath() == "" {
+		return nil, status.Error(codes.InvalidArgument, "TargetPath must be provided")
+	}
+
+	if request.GetVolumeCapability() == nil {
+		return nil, status.Error(codes.InvalidArgument, "VolumeCapability must be provided")
+	}
+
+	if err := checkVolumeCapabilities([]*csi.VolumeCapability{request.GetVolumeCapability()}); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	return nil, status.Errorf(codes.NotFound, "volume %s not found", request.GetVolumeId())
 }
 
 // NodeUnpublishVolume unmount the volume from the target path
 func (n *NodeService) NodeUnpublishVolume(_ context.Context, request *csi.NodeUnpublishVolumeRequest) (*csi.NodeUnpublishVolumeResponse, error) {
 	klog.V(4).InfoS("NodeUnpublishVolume: called", "args", protosanitizer.StripSecrets(request))
 
+	if request.GetVolumeId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "VolumeID must be provided")
+	}
+
+	if request.GetTargetPath() == "" {
+		return nil, status.Error(codes.InvalidArgument, "TargetPath must be provided")
+	}
+
+	// Nothing is published by the hybrid driver, the call is idempotent.
 	return &csi.NodeUnpublishVolumeResponse{}, nil
 }
 
@@ -99,14 +125,14 @@ func (n *NodeService) NodeUnpublishVolume(_ context.Context, request *csi.NodeUn
 func (n *NodeService) NodeGetVolumeStats(_ context.Context, request *csi.NodeGetVolumeStatsRequest) (*csi.NodeGetVolumeStatsResponse, error) {
 	klog.V(4).InfoS("NodeGetVolumeStats: called", "args", protosanitizer.StripSecrets(request))
 
-	return &csi.NodeGetVolumeStatsResponse{}, nil
+	return nil, status.Error(codes.Unimplemented, "")
 }
 
 // NodeExpandVolume expand the volume
 func (n *NodeService) NodeExpandVolume(_ context.Context, request *csi.NodeExpandVolumeRequest) (*csi.NodeExpandVolumeResponse, error) {
 	klog.V(4).InfoS("NodeExpandVolume: called", "args", protosanitizer.StripSecrets(request))
 
-	return &csi.NodeExpandVolumeResponse{}, nil
+	return nil, status.Error(codes.Unimplemented, "")
 }
 
 // NodeGetCapabilities get the node capabilities
@@ -138,24 +164,50 @@ func (n *NodeService) NodeGetInfo(ctx context.Context, _ *csi.NodeGetInfoRequest
 		return nil, fmt.Errorf("failed to get node %s: %w", n.nodeID, err)
 	}
 
-	region := node.Labels[corev1.LabelTopologyRegion]
-	if region == "" {
-		return nil, fmt.Errorf("failed to get region for node %s", n.nodeID)
+	segments, err := n.getNodeSegments(node)
+	if err != nil {
+		return nil, err
 	}
 
-	zone := node.Labels[corev1.LabelTopologyZone]
-	if zone == "" {
-		return nil, fmt.Errorf("failed to get zone for node %s", n.nodeID)
+	csiNode, err := n.getCSINode(ctx)
+	if err != nil {
+		return nil, err
 	}
 
+	klog.V(4).InfoS("NodeGetInfo: topology", "node", n.nodeID, "segments", segments)
+
+	setTopologySegments(segments)
+
 	return &csi.NodeGetInfoResponse{
 		NodeId:            n.nodeID,
-		MaxVolumesPerNode: MaxVolumesPerNode,
+		MaxVolumesPerNode: n.getMaxVolumesPerNode(csiNode),
 		AccessibleTopology: &csi.Topology{
-			Segments: map[string]string{
-				corev1.LabelTopologyRegion: region,
-				corev1.LabelTopologyZone:   zone,
-			},
+			Segments: segments,
 		},
 	}, nil
 }
+
+// checkVolumeCapabilities returns an error if one of the capabilities is not supported.
+func checkVolumeCapabilities(volCaps []*csi.VolumeCapability) error {
+	for _, c := range volCaps {
+		if c.GetBlock() == nil && c.GetMount() == nil {
+			return fmt.Errorf("access type must be block or mount")
+		}
+
+		if !isSupportedAccessMode(c.GetAccessMode().GetMode()) {
+			return fmt.Errorf("access mode %s is not supported", c.GetAccessMode().GetMode())
+		}
+	}
+
+	return nil
+}
+
+func isSupportedAccessMode(mode csi.VolumeCapability_AccessMode_Mode) bool {
+	for i := range volumeCaps {
+		if volumeCaps[i].GetMode() == mode {
+			return true
+		}
+	}
+
+	return false
+}
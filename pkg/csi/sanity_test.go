@@ -0,0 +1,84 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package csi
+
+import (
+	"net"
+	"path/filepath"
+	"testing"
+
+	"github.com/container-storage-interface/spec/lib/go/csi"
+	"github.com/kubernetes-csi/csi-test/v5/pkg/sanity"
+	"github.com/onsi/ginkgo/v2"
+	"github.com/onsi/gomega"
+	"google.golang.org/grpc"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// TestSanity runs the csi-sanity specs against the gRPC server of the driver.
+// The specs of the capabilities which the driver does not advertise are skipped by the suite,
+// the specs below create a volume without checking the capabilities, and the driver cannot create volumes.
+func TestSanity(t *testing.T) {
+	dir := t.TempDir()
+	endpoint := filepath.Join(dir, "csi.sock")
+
+	listener, err := net.Listen("unix", endpoint)
+	if err != nil {
+		t.Fatalf("failed to listen on %s: %v", endpoint, err)
+	}
+
+	client := fake.NewClientset(&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-1"}})
+
+	controllerService, err := NewControllerService(nil)
+	if err != nil {
+		t.Fatalf("failed to create controller service: %v", err)
+	}
+
+	srv := grpc.NewServer()
+	csi.RegisterIdentityServer(srv, NewIdentityService(NewReadiness("node-1", client, nil)))
+	csi.RegisterControllerServer(srv, controllerService)
+	csi.RegisterNodeServer(srv, NewNodeService("node-1", client, TopologyConfig{MissingKeyPolicy: TopologyMissingKeyOmit}, VolumeLimitConfig{Policy: VolumeLimitSum}))
+
+	go srv.Serve(listener) // nolint: errcheck
+
+	t.Cleanup(srv.Stop)
+
+	config := sanity.NewTestConfig()
+	config.Address = "unix://" + endpoint
+	config.ControllerAddress = "unix://" + endpoint
+	config.TargetPath = filepath.Join(dir, "target")
+	config.StagingPath = filepath.Join(dir, "staging")
+
+	sc := sanity.GinkgoTest(&config)
+
+	suiteConfig, reporterConfig := ginkgo.GinkgoConfiguration()
+	suiteConfig.SkipStrings = []string{
+		"ValidateVolumeCapabilities should fail when no volume capabilities are provided",
+		"ValidateVolumeCapabilities should return appropriate values",
+		"NodeUnpublishVolume should remove target path",
+		"Node Service should work",
+		"Node Service should be idempotent",
+	}
+
+	gomega.RegisterFailHandler(ginkgo.Fail)
+	ginkgo.RunSpecs(t, "CSI Driver Test Suite", suiteConfig, reporterConfig)
+
+	sc.Finalize()
+}
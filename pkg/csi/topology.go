@@ -0,0 +1,96 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package csi
+
+import (
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/klog/v2"
+)
+
+// TopologyBackendPrefix is the prefix of the node labels with the backend drivers available on the node.
+// The labels are maintained by the provisioner, so the prefix is reserved.
+const TopologyBackendPrefix = "backend." + DriverName + "/"
+
+const (
+	// TopologyMissingKeyOmit omits the topology key missing in the node labels.
+	TopologyMissingKeyOmit = "omit"
+	// TopologyMissingKeyDefault registers the topology key missing in the node labels with the default value.
+	TopologyMissingKeyDefault = "default"
+	// TopologyMissingKeyFail fails the node registration if a topology key is missing in the node labels.
+	TopologyMissingKeyFail = "fail"
+)
+
+// TopologyConfig defines the node labels registered as topology segments.
+type TopologyConfig struct {
+	// Keys is the list of node labels registered as topology segments.
+	Keys []string
+	// MissingKeyPolicy defines what to do if a key is missing in the node labels.
+	MissingKeyPolicy string
+	// DefaultValue is the value of the missing keys with the default policy.
+	DefaultValue string
+}
+
+// DefaultTopologyKeys is the default list of node labels registered as topology segments.
+var DefaultTopologyKeys = []string{corev1.LabelTopologyRegion, corev1.LabelTopologyZone}
+
+// Validate checks the topology configuration.
+func (c *TopologyConfig) Validate() error {
+	switch c.MissingKeyPolicy {
+	case TopologyMissingKeyOmit, TopologyMissingKeyFail:
+	case TopologyMissingKeyDefault:
+		if c.DefaultValue == "" {
+			return fmt.Errorf("default value is required for the %s missing key policy", c.MissingKeyPolicy)
+		}
+	default:
+		return fmt.Errorf("unknown missing key policy %q", c.MissingKeyPolicy)
+	}
+
+	for _, key := range c.Keys {
+		if strings.HasPrefix(key, TopologyBackendPrefix) {
+			return fmt.Errorf("topology key %s uses the reserved prefix %s", key, TopologyBackendPrefix)
+		}
+	}
+
+	return nil
+}
+
+// getNodeSegments returns the topology segments of the node labels.
+func (n *NodeService) getNodeSegments(node *corev1.Node) (map[string]string, error) {
+	segments := map[string]string{}
+
+	for _, key := range n.topology.Keys {
+		if v := node.Labels[key]; v != "" {
+			segments[key] = v
+
+			continue
+		}
+
+		switch n.topology.MissingKeyPolicy {
+		case TopologyMissingKeyOmit:
+			klog.V(4).InfoS("Topology label is missing on the node, omitted", "node", klog.KObj(node), "key", key)
+		case TopologyMissingKeyDefault:
+			segments[key] = n.topology.DefaultValue
+		default:
+			return nil, fmt.Errorf("failed to get label %s for node %s", key, n.nodeID)
+		}
+	}
+
+	return segments, nil
+}
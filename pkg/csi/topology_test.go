@@ -0,0 +1,99 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package csi
+
+import (
+	"maps"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestTopologyConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  TopologyConfig
+		wantErr bool
+	}{
+		{name: "fail policy", config: TopologyConfig{Keys: DefaultTopologyKeys, MissingKeyPolicy: TopologyMissingKeyFail}},
+		{name: "omit policy", config: TopologyConfig{MissingKeyPolicy: TopologyMissingKeyOmit}},
+		{name: "default policy", config: TopologyConfig{MissingKeyPolicy: TopologyMissingKeyDefault, DefaultValue: "unknown"}},
+		{name: "default policy without value", config: TopologyConfig{MissingKeyPolicy: TopologyMissingKeyDefault}, wantErr: true},
+		{name: "unknown policy", config: TopologyConfig{MissingKeyPolicy: "ignore"}, wantErr: true},
+		{
+			name:    "reserved prefix",
+			config:  TopologyConfig{Keys: []string{TopologyBackendPrefix + "a.csi"}, MissingKeyPolicy: TopologyMissingKeyFail},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.config.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestGetNodeSegments(t *testing.T) {
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   "node-1",
+			Labels: map[string]string{corev1.LabelTopologyRegion: "region-1"},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		config  TopologyConfig
+		want    map[string]string
+		wantErr bool
+	}{
+		{
+			name:    "fail on missing key",
+			config:  TopologyConfig{Keys: DefaultTopologyKeys, MissingKeyPolicy: TopologyMissingKeyFail},
+			wantErr: true,
+		},
+		{
+			name:   "omit missing key",
+			config: TopologyConfig{Keys: DefaultTopologyKeys, MissingKeyPolicy: TopologyMissingKeyOmit},
+			want:   map[string]string{corev1.LabelTopologyRegion: "region-1"},
+		},
+		{
+			name:   "default value of missing key",
+			config: TopologyConfig{Keys: DefaultTopologyKeys, MissingKeyPolicy: TopologyMissingKeyDefault, DefaultValue: "unknown"},
+			want:   map[string]string{corev1.LabelTopologyRegion: "region-1", corev1.LabelTopologyZone: "unknown"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n := &NodeService{nodeID: node.Name, topology: tt.config}
+
+			got, err := n.getNodeSegments(node)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("getNodeSegments() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if !tt.wantErr && !maps.Equal(got, tt.want) {
+				t.Errorf("getNodeSegments() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
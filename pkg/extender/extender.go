@@ -0,0 +1,255 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package extender
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/sergelogvinov/hybrid-csi-plugin/pkg/provisioner"
+
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	storagelistersv1 "k8s.io/client-go/listers/storage/v1"
+	"k8s.io/klog/v2"
+)
+
+// Extender is the kube-scheduler extender of the hybrid provisioner.
+type Extender struct {
+	provisioner *provisioner.HybridProvisioner
+
+	scLister    storagelistersv1.StorageClassLister
+	claimLister corelisters.PersistentVolumeClaimLister
+	nodeLister  corelisters.NodeLister
+}
+
+// hybridClaim is an unbound claim of the pod with a hybrid StorageClass.
+type hybridClaim struct {
+	Claim        *corev1.PersistentVolumeClaim
+	StorageClass *storagev1.StorageClass
+}
+
+// NewExtender creates a new scheduler extender
+func NewExtender(
+	p *provisioner.HybridProvisioner,
+	scLister storagelistersv1.StorageClassLister,
+	claimLister corelisters.PersistentVolumeClaimLister,
+	nodeLister corelisters.NodeLister,
+) *Extender {
+	return &Extender{
+		provisioner: p,
+		scLister:    scLister,
+		claimLister: claimLister,
+		nodeLister:  nodeLister,
+	}
+}
+
+// RegisterHandlers registers the filter and prioritize handlers.
+func (e *Extender) RegisterHandlers(mux *http.ServeMux) {
+	mux.HandleFunc("POST /filter", e.handleFilter)
+	mux.HandleFunc("POST /prioritize", e.handlePrioritize)
+}
+
+func (e *Extender) handleFilter(w http.ResponseWriter, r *http.Request) {
+	var args ExtenderArgs
+
+	if err := json.NewDecoder(r.Body).Decode(&args); err != nil {
+		writeJSON(w, &ExtenderFilterResult{Error: fmt.Sprintf("failed to decode request: %v", err)})
+
+		return
+	}
+
+	writeJSON(w, e.Filter(&args))
+}
+
+func (e *Extender) handlePrioritize(w http.ResponseWriter, r *http.Request) {
+	var args ExtenderArgs
+
+	if err := json.NewDecoder(r.Body).Decode(&args); err != nil {
+		http.Error(w, fmt.Sprintf("failed to decode request: %v", err), http.StatusBadRequest)
+
+		return
+	}
+
+	writeJSON(w, e.Prioritize(&args))
+}
+
+// Filter filters out the nodes where a hybrid volume of the pod has no eligible backend.
+func (e *Extender) Filter(args *ExtenderArgs) *ExtenderFilterResult {
+	claims, err := e.getHybridClaims(args.Pod)
+	if err != nil {
+		return &ExtenderFilterResult{Error: err.Error()}
+	}
+
+	nodes := e.getNodes(args)
+	res := &ExtenderFilterResult{FailedNodes: map[string]string{}}
+
+	var passed []*corev1.Node
+
+	for _, node := range nodes {
+		if reason := e.checkNode(claims, node); reason != "" {
+			res.FailedNodes[node.Name] = reason
+
+			continue
+		}
+
+		passed = append(passed, node)
+	}
+
+	klog.V(4).InfoS("Filter: called", "pod", klog.KObj(args.Pod), "nodes", len(nodes), "passed", len(passed))
+
+	if args.NodeNames != nil {
+		names := make([]string, 0, len(passed))
+		for _, node := range passed {
+			names = append(names, node.Name)
+		}
+
+		res.NodeNames = &names
+	} else {
+		list := &corev1.NodeList{}
+		for _, node := range passed {
+			list.Items = append(list.Items, *node)
+		}
+
+		res.Nodes = list
+	}
+
+	return res
+}
+
+// Prioritize scores the nodes by the priority of the backends the hybrid volumes of the pod would get.
+func (e *Extender) Prioritize(args *ExtenderArgs) *HostPriorityList {
+	res := HostPriorityList{}
+
+	claims, err := e.getHybridClaims(args.Pod)
+	if err != nil {
+		klog.ErrorS(err, "Failed to get hybrid claims of the pod", "pod", klog.KObj(args.Pod))
+	}
+
+	for _, node := range e.getNodes(args) {
+		res = append(res, HostPriority{Host: node.Name, Score: e.scoreNode(claims, node)})
+	}
+
+	return &res
+}
+
+// checkNode returns the reason why the node cannot serve the hybrid claims, or an empty string.
+func (e *Extender) checkNode(claims []hybridClaim, node *corev1.Node) string {
+	for _, c := range claims {
+		if _, err := e.provisioner.EvaluateNode(c.Claim, c.StorageClass, node); err != nil {
+			return fmt.Sprintf("volume %s: %v", c.Claim.Name, err)
+		}
+	}
+
+	return ""
+}
+
+// scoreNode returns the average score of the backends the hybrid claims would get on the node,
+// the first backend of the list has the maximum score.
+func (e *Extender) scoreNode(claims []hybridClaim, node *corev1.Node) int64 {
+	if len(claims) == 0 {
+		return 0
+	}
+
+	var score int64
+
+	for _, c := range claims {
+		sel, err := e.provisioner.EvaluateNode(c.Claim, c.StorageClass, node)
+		if err != nil {
+			continue
+		}
+
+		score += MaxExtenderPriority * int64(sel.Backends-sel.Rank) / int64(sel.Backends)
+	}
+
+	return score / int64(len(claims))
+}
+
+// getHybridClaims returns the unbound claims of the pod with a hybrid StorageClass.
+// The claims which are not in the informer cache yet are skipped, so the pod is not rejected by the filter.
+func (e *Extender) getHybridClaims(pod *corev1.Pod) ([]hybridClaim, error) {
+	if pod == nil {
+		return nil, fmt.Errorf("pod is required")
+	}
+
+	var claims []hybridClaim
+
+	for _, v := range pod.Spec.Volumes {
+		if v.PersistentVolumeClaim == nil {
+			continue
+		}
+
+		claim, err := e.claimLister.PersistentVolumeClaims(pod.Namespace).Get(v.PersistentVolumeClaim.ClaimName)
+		if err != nil {
+			// The claim is not in the cache yet, the provisioning checks it later.
+			klog.V(4).InfoS("Skip persistentvolumeclaim of the pod", "pod", klog.KObj(pod), "PVC", v.PersistentVolumeClaim.ClaimName, "err", err)
+
+			continue
+		}
+
+		if claim.Spec.VolumeName != "" || claim.Spec.StorageClassName == nil {
+			continue
+		}
+
+		class, err := e.scLister.Get(*claim.Spec.StorageClassName)
+		if err != nil || !provisioner.IsHybridStorageClass(class) {
+			continue
+		}
+
+		claims = append(claims, hybridClaim{Claim: claim, StorageClass: class})
+	}
+
+	return claims, nil
+}
+
+// getNodes returns the candidate nodes of the request.
+func (e *Extender) getNodes(args *ExtenderArgs) []*corev1.Node {
+	var nodes []*corev1.Node
+
+	if args.Nodes != nil {
+		for i := range args.Nodes.Items {
+			nodes = append(nodes, &args.Nodes.Items[i])
+		}
+
+		return nodes
+	}
+
+	if args.NodeNames != nil {
+		for _, name := range *args.NodeNames {
+			node, err := e.nodeLister.Get(name)
+			if err != nil {
+				klog.V(4).InfoS("Node is not found", "node", name)
+
+				continue
+			}
+
+			nodes = append(nodes, node)
+		}
+	}
+
+	return nodes
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		klog.ErrorS(err, "Failed to write response")
+	}
+}
@@ -0,0 +1,108 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package extender
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/sergelogvinov/hybrid-csi-plugin/pkg/provisioner"
+
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestGetHybridClaims(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	hybrid := "hybrid"
+	backend := "backend"
+	volume := "pvc-bound"
+
+	client := fake.NewClientset(
+		&storagev1.StorageClass{ObjectMeta: metav1.ObjectMeta{Name: hybrid}, Provisioner: provisioner.DriverName},
+		&storagev1.StorageClass{ObjectMeta: metav1.ObjectMeta{Name: backend}, Provisioner: "backend.csi"},
+		&corev1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{Name: "pending", Namespace: "default"},
+			Spec:       corev1.PersistentVolumeClaimSpec{StorageClassName: &hybrid},
+		},
+		&corev1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{Name: "bound", Namespace: "default"},
+			Spec:       corev1.PersistentVolumeClaimSpec{StorageClassName: &hybrid, VolumeName: volume},
+		},
+		&corev1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{Name: "backend", Namespace: "default"},
+			Spec:       corev1.PersistentVolumeClaimSpec{StorageClassName: &backend},
+		},
+	)
+	factory := informers.NewSharedInformerFactory(client, 0)
+
+	e := NewExtender(nil,
+		factory.Storage().V1().StorageClasses().Lister(),
+		factory.Core().V1().PersistentVolumeClaims().Lister(),
+		factory.Core().V1().Nodes().Lister(),
+	)
+
+	factory.Start(ctx.Done())
+	factory.WaitForCacheSync(ctx.Done())
+
+	tests := []struct {
+		name    string
+		volumes []string
+		want    []string
+	}{
+		{name: "pending claim", volumes: []string{"pending"}, want: []string{"pending"}},
+		{name: "bound claim", volumes: []string{"bound"}},
+		{name: "claim of another storage class", volumes: []string{"backend"}},
+		{name: "missing claim", volumes: []string{"missing"}},
+		{name: "all claims", volumes: []string{"pending", "bound", "backend", "missing"}, want: []string{"pending"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "default"}}
+
+			for _, name := range tt.volumes {
+				pod.Spec.Volumes = append(pod.Spec.Volumes, corev1.Volume{
+					Name: name,
+					VolumeSource: corev1.VolumeSource{
+						PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: name},
+					},
+				})
+			}
+
+			claims, err := e.getHybridClaims(pod)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			var got []string
+			for _, c := range claims {
+				got = append(got, c.Claim.Name)
+			}
+
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("getHybridClaims() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package extender
+
+import (
+	corev1 "k8s.io/api/core/v1"
+)
+
+// The scheduler extender API types, compatible with k8s.io/kube-scheduler/extender/v1.
+
+// MaxExtenderPriority is the maximum score of a node.
+const MaxExtenderPriority int64 = 10
+
+// ExtenderArgs is the arguments of the filter and prioritize requests.
+type ExtenderArgs struct {
+	// Pod being scheduled
+	Pod *corev1.Pod `json:"pod"`
+	// List of candidate nodes, if the extender is not node cache capable
+	Nodes *corev1.NodeList `json:"nodes,omitempty"`
+	// List of candidate node names, if the extender is node cache capable
+	NodeNames *[]string `json:"nodenames,omitempty"`
+}
+
+// ExtenderFilterResult is the result of the filter request.
+type ExtenderFilterResult struct {
+	// Filtered set of nodes, if the extender is not node cache capable
+	Nodes *corev1.NodeList `json:"nodes,omitempty"`
+	// Filtered set of node names, if the extender is node cache capable
+	NodeNames *[]string `json:"nodenames,omitempty"`
+	// Filtered out nodes with the failure reason
+	FailedNodes map[string]string `json:"failedNodes,omitempty"`
+	// Filtered out nodes, where preemption would not help
+	FailedAndUnresolvableNodes map[string]string `json:"failedAndUnresolvableNodes,omitempty"`
+	// Error message
+	Error string `json:"error,omitempty"`
+}
+
+// HostPriority is the score of a node.
+type HostPriority struct {
+	Host  string `json:"host"`
+	Score int64  `json:"score"`
+}
+
+// HostPriorityList is the result of the prioritize request.
+type HostPriorityList []HostPriority
@@ -0,0 +1,155 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provisioner
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	controller "sigs.k8s.io/sig-storage-lib-external-provisioner/v10/controller"
+
+	"github.com/sergelogvinov/hybrid-csi-plugin/pkg/tools"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/component-helpers/storage/volume"
+	"k8s.io/klog/v2"
+)
+
+// nodeAffinityError is returned when the backend volume is not accessible from the selected node.
+type nodeAffinityError struct {
+	PV   string
+	Node string
+	Err  error
+}
+
+func (e *nodeAffinityError) Error() string {
+	return fmt.Sprintf("persistent volume %s is not accessible from node %s: %v", e.PV, e.Node, e.Err)
+}
+
+func (e *nodeAffinityError) Unwrap() error {
+	return e.Err
+}
+
+func isNodeAffinityError(err error) bool {
+	var e *nodeAffinityError
+
+	return errors.As(err, &e)
+}
+
+// checkPVNodeAffinity verifies that the backend volume is accessible from the selected node.
+// If it is not, the volume is released to the backend provisioner for deletion.
+func (p *HybridProvisioner) checkPVNodeAffinity(
+	ctx context.Context,
+	opts controller.ProvisionOptions,
+	pl *placement,
+	pvc *corev1.PersistentVolumeClaim,
+	pv *corev1.PersistentVolume,
+) error {
+	err := volume.CheckNodeAffinity(pv, opts.SelectedNode.Labels)
+	if err == nil {
+		return nil
+	}
+
+	storageClass := pl.StorageClass
+
+	klog.ErrorS(err, "Persistent volume is not accessible from the selected node", "PV", klog.KObj(pv), "node", klog.KObj(opts.SelectedNode), "storageClass", klog.KObj(storageClass))
+
+	p.recorder.Eventf(opts.PVC, corev1.EventTypeWarning, "NodeAffinityMismatch",
+		"Volume %s provisioned by storage class %s is not accessible from node %s: %v", pv.Name, storageClass.Name, opts.SelectedNode.Name, err)
+
+	if err := p.deletePV(ctx, pv.Name, pvc); err != nil {
+		return err
+	}
+
+	// The claims queued on the backend do not wait for the deletion of a rejected volume.
+	p.releaseSlot(ctx, storageClass.Name, opts.PVC.UID)
+
+	// Wait for the backend to free the volume before the next attempt.
+	if err := tools.PVWaitDelete(ctx, p.client, pv.Name, pl.Timeouts.Delete); err != nil {
+		klog.ErrorS(err, "Persistent volume is not deleted by the backend", "PV", klog.KObj(pv), "storageClass", klog.KObj(storageClass))
+	}
+
+	return &nodeAffinityError{PV: pv.Name, Node: opts.SelectedNode.Name, Err: err}
+}
+
+// deletePV hands the released volume back to the backend provisioner for deletion.
+// The volume is bound to the deleted provisioning claim, so the PV controller will delete it.
+func (p *HybridProvisioner) deletePV(ctx context.Context, pvName string, pvc *corev1.PersistentVolumeClaim) error {
+	patch, _ := json.Marshal(&corev1.PersistentVolume{ // nolint: errcheck,errchkjson
+		Spec: corev1.PersistentVolumeSpec{
+			PersistentVolumeReclaimPolicy: corev1.PersistentVolumeReclaimDelete,
+			ClaimRef: &corev1.ObjectReference{
+				APIVersion: "v1",
+				Kind:       "PersistentVolumeClaim",
+				Name:       pvc.Name,
+				Namespace:  pvc.Namespace,
+				UID:        pvc.UID,
+			},
+		},
+	})
+
+	if _, err := p.client.CoreV1().PersistentVolumes().Patch(ctx, pvName, types.MergePatchType, patch, metav1.PatchOptions{}); err != nil {
+		return fmt.Errorf("failed to patch PersistentVolume: %v", err)
+	}
+
+	return nil
+}
+
+// exclusion is the list of backend StorageClasses excluded for a claim on the selected node.
+type exclusion struct {
+	Node           string
+	StorageClasses []string
+}
+
+// excludeStorageClass excludes the backend StorageClass from the next provisioning attempts of the claim on the node.
+func (p *HybridProvisioner) excludeStorageClass(claim types.UID, node, storageClass string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	e, ok := p.excluded[claim]
+	if !ok || e.Node != node {
+		e = &exclusion{Node: node}
+		p.excluded[claim] = e
+	}
+
+	e.StorageClasses = append(e.StorageClasses, storageClass)
+}
+
+// getExcludedStorageClasses returns the backend StorageClasses excluded for the claim on the node.
+// The exclusions of another node do not apply, the backends may work there.
+func (p *HybridProvisioner) getExcludedStorageClasses(claim types.UID, node string) []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if e, ok := p.excluded[claim]; ok && e.Node == node {
+		return e.StorageClasses
+	}
+
+	return nil
+}
+
+// resetExcludedStorageClasses forgets the exclusions of the claim, when it is provisioned, rescheduled or deleted.
+func (p *HybridProvisioner) resetExcludedStorageClasses(claim types.UID) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	delete(p.excluded, claim)
+}
@@ -0,0 +1,140 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provisioner
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	controller "sigs.k8s.io/sig-storage-lib-external-provisioner/v10/controller"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestExcludedStorageClasses(t *testing.T) {
+	// exclusion is the backend StorageClass excluded for the claim on the node.
+	type exclusion struct {
+		claim, node, storageClass string
+	}
+
+	exclusions := []exclusion{
+		{claim: "uid-1", node: "node-1", storageClass: "a"},
+		{claim: "uid-1", node: "node-1", storageClass: "b"},
+		{claim: "uid-2", node: "node-1", storageClass: "c"},
+	}
+
+	tests := []struct {
+		name       string
+		exclusions []exclusion
+		deleted    string
+		claim      string
+		node       string
+		want       []string
+	}{
+		{name: "same node", exclusions: exclusions, claim: "uid-1", node: "node-1", want: []string{"a", "b"}},
+		{name: "another node", exclusions: exclusions, claim: "uid-1", node: "node-2"},
+		{
+			// A new node replaces the exclusions of the previous one.
+			name:       "previous node",
+			exclusions: append(slices.Clone(exclusions), exclusion{claim: "uid-1", node: "node-2", storageClass: "c"}),
+			claim:      "uid-1",
+			node:       "node-1",
+		},
+		{
+			name:       "new node",
+			exclusions: append(slices.Clone(exclusions), exclusion{claim: "uid-1", node: "node-2", storageClass: "c"}),
+			claim:      "uid-1",
+			node:       "node-2",
+			want:       []string{"c"},
+		},
+		{name: "deleted claim", exclusions: exclusions, deleted: "uid-2", claim: "uid-2", node: "node-1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, _ := newTestProvisioner(t)
+
+			for _, e := range tt.exclusions {
+				p.excludeStorageClass(types.UID(e.claim), e.node, e.storageClass)
+			}
+
+			if tt.deleted != "" {
+				claim := newTestClaim("pvc", "1Gi")
+				claim.UID = types.UID(tt.deleted)
+
+				p.onClaimDeleted(claim)
+			}
+
+			if got := p.getExcludedStorageClasses(types.UID(tt.claim), tt.node); !slices.Equal(got, tt.want) {
+				t.Errorf("getExcludedStorageClasses() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckPVNodeAffinityReleasesSlot(t *testing.T) {
+	first := newTestClaim("first", "1Gi")
+	second := newTestClaim("second", "1Gi")
+
+	pv := &corev1.PersistentVolume{
+		ObjectMeta: metav1.ObjectMeta{Name: "pv-1"},
+		Spec: corev1.PersistentVolumeSpec{
+			NodeAffinity: &corev1.VolumeNodeAffinity{
+				Required: &corev1.NodeSelector{
+					NodeSelectorTerms: []corev1.NodeSelectorTerm{{
+						MatchExpressions: []corev1.NodeSelectorRequirement{{
+							Key:      corev1.LabelHostname,
+							Operator: corev1.NodeSelectorOpIn,
+							Values:   []string{"node-2"},
+						}},
+					}},
+				},
+			},
+		},
+	}
+
+	p, _ := newTestProvisioner(t, first, second, pv)
+
+	p.acquireSlot("backend", first, 1)
+
+	if _, ok := p.acquireSlot("backend", second, 1); ok {
+		t.Fatalf("second claim got a slot over the limit")
+	}
+
+	opts := controller.ProvisionOptions{
+		PVC:          first,
+		SelectedNode: newTestNode("node-1", map[string]string{corev1.LabelHostname: "node-1"}),
+	}
+	pl := &placement{
+		StorageClass: newTestStorageClass("backend", "csi.backend.com", nil),
+		Timeouts:     &backendTimeouts{Delete: 10 * time.Millisecond},
+	}
+
+	err := p.checkPVNodeAffinity(context.Background(), opts, pl, first, pv)
+	if !isNodeAffinityError(err) {
+		t.Fatalf("checkPVNodeAffinity() error = %v, want node affinity error", err)
+	}
+
+	// The volume is not deleted by the fake backend, the slot is released before the wait.
+	if _, ok := p.acquireSlot("backend", second, 1); !ok {
+		t.Errorf("waiting claim did not get the slot of the rejected volume")
+	}
+}
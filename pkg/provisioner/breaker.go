@@ -0,0 +1,132 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provisioner
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/klog/v2"
+)
+
+// annMaintenance is the backend StorageClass annotation, which excludes the backend from new placements.
+const annMaintenance = DriverName + "/maintenance"
+
+// backendHealth is the circuit breaker state of a backend StorageClass.
+type backendHealth struct {
+	// Failures is the number of consecutive provisioning failures.
+	Failures int
+	// OpenUntil is the time until the backend is skipped.
+	OpenUntil time.Time
+}
+
+// checkBackendAvailable returns an error if the backend StorageClass is in maintenance or its circuit breaker is open.
+func (p *HybridProvisioner) checkBackendAvailable(class *storagev1.StorageClass) error {
+	if inMaintenance(class) {
+		return fmt.Errorf("backend is in maintenance")
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if h, ok := p.health[class.Name]; ok && time.Now().Before(h.OpenUntil) {
+		return fmt.Errorf("circuit breaker is open after %d failures, until %s", h.Failures, h.OpenUntil.UTC().Format(time.RFC3339))
+	}
+
+	return nil
+}
+
+// updateBackendMetrics exports the maintenance and circuit breaker state of the backend StorageClasses.
+// The breaker closes by itself after the cooldown, so the state is exported on each provisioning.
+func (p *HybridProvisioner) updateBackendMetrics(storageClasses []string) {
+	now := time.Now()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, name := range storageClasses {
+		class, err := p.scLister.Get(name)
+		if err != nil {
+			continue
+		}
+
+		h, ok := p.health[name]
+
+		backendMaintenance.WithLabelValues(name).Set(boolToFloat(inMaintenance(class)))
+		backendCircuitOpen.WithLabelValues(name).Set(boolToFloat(ok && now.Before(h.OpenUntil)))
+	}
+}
+
+func boolToFloat(b bool) float64 {
+	if b {
+		return 1
+	}
+
+	return 0
+}
+
+func inMaintenance(class *storagev1.StorageClass) bool {
+	maintenance, _ := strconv.ParseBool(class.Annotations[annMaintenance]) // nolint: errcheck
+
+	return maintenance
+}
+
+// recordBackendFailure counts the provisioning failure of the backend StorageClass.
+// The circuit breaker opens when the failure threshold is reached, and is open again on each failure after the cooldown,
+// until the backend succeeds.
+func (p *HybridProvisioner) recordBackendFailure(storageClass string) {
+	cb := p.config.Get().CircuitBreaker
+
+	backendFailures.WithLabelValues(storageClass).Inc()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	h, ok := p.health[storageClass]
+	if !ok {
+		h = &backendHealth{}
+		p.health[storageClass] = h
+	}
+
+	h.Failures++
+
+	if cb.FailureThreshold > 0 && h.Failures >= cb.FailureThreshold {
+		h.OpenUntil = time.Now().Add(cb.OpenDuration.Duration)
+
+		klog.InfoS("Circuit breaker is open", "storageClass", storageClass, "failures", h.Failures, "until", h.OpenUntil)
+
+		backendCircuitOpen.WithLabelValues(storageClass).Set(1)
+		backendCircuitOpenUntil.WithLabelValues(storageClass).Set(float64(h.OpenUntil.Unix()))
+	}
+}
+
+// recordBackendSuccess closes the circuit breaker of the backend StorageClass.
+func (p *HybridProvisioner) recordBackendSuccess(storageClass string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if _, ok := p.health[storageClass]; ok {
+		delete(p.health, storageClass)
+
+		klog.InfoS("Circuit breaker is closed", "storageClass", storageClass)
+	}
+
+	backendCircuitOpen.WithLabelValues(storageClass).Set(0)
+	backendCircuitOpenUntil.WithLabelValues(storageClass).Set(0)
+}
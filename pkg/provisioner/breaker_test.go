@@ -0,0 +1,121 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provisioner
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	class := newTestStorageClass("backend", "backend.csi", nil)
+
+	p, _ := newTestProvisioner(t, class)
+	threshold := p.config.Get().CircuitBreaker.FailureThreshold
+
+	steps := []struct {
+		name     string
+		failures int
+		cooldown bool
+		success  bool
+		wantOpen bool
+	}{
+		{name: "below the threshold", failures: threshold - 1},
+		{name: "at the threshold", failures: 1, wantOpen: true},
+		{name: "after the cooldown", cooldown: true},
+		// A failure after the cooldown opens the breaker again.
+		{name: "failure after the cooldown", failures: 1, wantOpen: true},
+		{name: "success", success: true},
+	}
+
+	for _, step := range steps {
+		for range step.failures {
+			p.recordBackendFailure(class.Name)
+		}
+
+		if step.cooldown {
+			p.health[class.Name].OpenUntil = time.Now().Add(-time.Second)
+		}
+
+		if step.success {
+			p.recordBackendSuccess(class.Name)
+		}
+
+		if err := p.checkBackendAvailable(class); (err != nil) != step.wantOpen {
+			t.Fatalf("%s: checkBackendAvailable() error = %v, want open %v", step.name, err, step.wantOpen)
+		}
+
+		// The breaker closes by itself after the cooldown, the metrics are exported on each provisioning.
+		p.updateBackendMetrics([]string{class.Name})
+
+		want := 0.0
+		if step.wantOpen {
+			want = 1
+		}
+
+		if v := testutil.ToFloat64(backendCircuitOpen.WithLabelValues(class.Name)); v != want {
+			t.Errorf("%s: backend_circuit_open = %v, want %v", step.name, v, want)
+		}
+
+		if v := testutil.ToFloat64(backendCircuitOpenUntil.WithLabelValues(class.Name)); step.success && v != 0 {
+			t.Errorf("%s: backend_circuit_open_until_timestamp_seconds = %v, want 0", step.name, v)
+		}
+	}
+}
+
+func TestCheckBackendAvailableMaintenance(t *testing.T) {
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		want        bool
+	}{
+		{name: "in-maintenance", annotations: map[string]string{annMaintenance: "true"}},
+		{name: "maintenance-disabled", annotations: map[string]string{annMaintenance: "false"}, want: true},
+		{name: "invalid-value", annotations: map[string]string{annMaintenance: "yes"}, want: true},
+		{name: "no-annotation", want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			class := newTestStorageClass(tt.name, "backend.csi", tt.annotations)
+
+			p, _ := newTestProvisioner(t, class)
+
+			if err := p.checkBackendAvailable(class); (err == nil) != tt.want {
+				t.Errorf("checkBackendAvailable() error = %v, want available %v", err, tt.want)
+			}
+
+			// The check is used by the scheduler extender, it does not export the state.
+			if v := testutil.ToFloat64(backendMaintenance.WithLabelValues(class.Name)); v != 0 {
+				t.Errorf("backend_maintenance = %v after the check, want 0", v)
+			}
+
+			p.updateBackendMetrics([]string{class.Name})
+
+			want := 1.0
+			if tt.want {
+				want = 0
+			}
+
+			if v := testutil.ToFloat64(backendMaintenance.WithLabelValues(class.Name)); v != want {
+				t.Errorf("backend_maintenance = %v, want %v", v, want)
+			}
+		})
+	}
+}
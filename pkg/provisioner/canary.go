@@ -0,0 +1,322 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provisioner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/sergelogvinov/hybrid-csi-plugin/pkg/config"
+	"github.com/sergelogvinov/hybrid-csi-plugin/pkg/tools"
+
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog/v2"
+)
+
+// labelCanary marks the canary objects.
+const labelCanary = DriverName + "/canary"
+
+// errCanaryBusy is returned when the backend has no free provisioning slot for the canary.
+var errCanaryBusy = errors.New("backend has no free provisioning slot")
+
+// canaryTarget is a backend StorageClass and the representative node of a topology segment.
+type canaryTarget struct {
+	StorageClass *storagev1.StorageClass
+	Policy       *hybridPolicy
+	Segment      string
+	Node         *corev1.Node
+}
+
+// RunCanary periodically provisions a canary volume through each backend StorageClass of the hybrid StorageClasses,
+// on a representative node of each topology segment, using the pod method. The settings are read from the configuration on each run.
+func (p *HybridProvisioner) RunCanary(ctx context.Context) {
+	for {
+		c := p.config.Get().Canary
+
+		if c.Enabled {
+			p.runCanaries(ctx, c)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(c.Interval.Duration):
+		}
+	}
+}
+
+func (p *HybridProvisioner) runCanaries(ctx context.Context, c config.Canary) {
+	// Leftovers of an interrupted run.
+	selector := labels.SelectorFromSet(labels.Set{labelCanary: "true"}).String()
+
+	pvcs, err := p.client.CoreV1().PersistentVolumeClaims(c.Namespace).List(ctx, metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		klog.ErrorS(err, "Failed to list canary persistent volume claims", "namespace", c.Namespace)
+
+		return
+	}
+
+	for _, pvc := range pvcs.Items {
+		if err := p.deleteCanary(ctx, c.Namespace, pvc.Name); err != nil {
+			klog.ErrorS(err, "Failed to delete canary", "PVC", klog.KObj(&pvc))
+		}
+	}
+
+	for _, t := range p.getCanaryTargets(c.TopologyKey) {
+		if ctx.Err() != nil {
+			return
+		}
+
+		start := time.Now()
+
+		err := p.provisionCanary(ctx, c, t)
+		if errors.Is(err, errCanaryBusy) {
+			klog.V(4).InfoS("Skipping canary, the backend has no free provisioning slot", "node", klog.KObj(t.Node), "storageClass", klog.KObj(t.StorageClass), "segment", t.Segment)
+
+			continue
+		}
+
+		p.setCanaryResult(t, time.Since(start), err)
+	}
+}
+
+// getCanaryTargets returns the backend StorageClasses of the hybrid StorageClasses,
+// with the first suitable node of each topology segment.
+func (p *HybridProvisioner) getCanaryTargets(topologyKey string) []canaryTarget {
+	classes, err := p.scLister.List(labels.Everything())
+	if err != nil {
+		klog.ErrorS(err, "Failed to list storage classes")
+
+		return nil
+	}
+
+	slices.SortFunc(classes, func(a, b *storagev1.StorageClass) int { return strings.Compare(a.Name, b.Name) })
+
+	backends := map[string]*hybridPolicy{}
+	names := []string{}
+
+	for _, class := range classes {
+		if class.Provisioner != DriverName {
+			continue
+		}
+
+		policy, err := p.getHybridPolicy(class)
+		if err != nil {
+			klog.V(4).InfoS("Skipping canary of hybrid storage class", "storageClass", klog.KObj(class), "reason", err.Error())
+
+			continue
+		}
+
+		for _, name := range policy.StorageClasses {
+			if _, ok := backends[name]; !ok {
+				backends[name] = policy
+				names = append(names, name)
+			}
+		}
+	}
+
+	nodes, err := p.nodeLister.List(labels.Everything())
+	if err != nil {
+		klog.ErrorS(err, "Failed to list nodes")
+
+		return nil
+	}
+
+	slices.SortFunc(nodes, func(a, b *corev1.Node) int { return strings.Compare(a.Name, b.Name) })
+
+	var targets []canaryTarget
+
+	for _, name := range names {
+		class, err := p.scLister.Get(name)
+		if err != nil {
+			continue
+		}
+
+		if inMaintenance(class) {
+			continue
+		}
+
+		segments := map[string]bool{}
+
+		for _, node := range nodes {
+			segment := node.Labels[topologyKey]
+			if node.Spec.Unschedulable || segments[segment] {
+				continue
+			}
+
+			// A missing CSINode is reported by checkStorageClass for the CSI backends.
+			csiNode, _ := p.csiNodeLister.Get(node.Name) // nolint: errcheck
+
+			if err := p.checkStorageClass(node, csiNode, nil, class, backends[name]); err != nil {
+				continue
+			}
+
+			segments[segment] = true
+			targets = append(targets, canaryTarget{StorageClass: class, Policy: backends[name], Segment: segment, Node: node})
+		}
+	}
+
+	return targets
+}
+
+// provisionCanary provisions the canary volume on the node of the target and deletes it.
+func (p *HybridProvisioner) provisionCanary(ctx context.Context, c config.Canary, t canaryTarget) error {
+	timeouts, err := p.getBackendTimeouts(t.StorageClass)
+	if err != nil {
+		return err
+	}
+
+	caps, err := t.Policy.getCapabilities(t.StorageClass)
+	if err != nil {
+		return err
+	}
+
+	accessMode := corev1.ReadWriteOnce
+	if len(caps.AccessModes) > 0 && !slices.Contains(caps.AccessModes, accessMode) {
+		accessMode = caps.AccessModes[0]
+	}
+
+	size := c.Size.DeepCopy()
+	if caps.MinSize != nil && size.Cmp(*caps.MinSize) < 0 {
+		size = caps.MinSize.DeepCopy()
+	}
+
+	limit, err := p.getMaxInFlight(t.StorageClass)
+	if err != nil {
+		return err
+	}
+
+	h := fnv.New32a()
+	h.Write([]byte(t.StorageClass.Name + "/" + t.Segment)) // nolint: errcheck
+
+	pvc := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("hybrid-canary-%08x", h.Sum32()),
+			Namespace: c.Namespace,
+			Labels:    map[string]string{labelCanary: "true"},
+		},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			AccessModes:      []corev1.PersistentVolumeAccessMode{accessMode},
+			StorageClassName: &t.StorageClass.Name,
+			Resources: corev1.VolumeResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceStorage: size},
+			},
+		},
+	}
+
+	// The canary counts against the in-flight limit of the backend, but never waits in its queue.
+	slot := types.UID(pvc.Namespace + "/" + pvc.Name)
+	if !p.tryAcquireSlot(t.StorageClass.Name, slot, limit) {
+		return errCanaryBusy
+	}
+
+	defer p.releaseSlot(ctx, t.StorageClass.Name, slot)
+
+	klog.V(4).InfoS("Provisioning canary volume", "PVC", klog.KObj(pvc), "node", klog.KObj(t.Node), "storageClass", klog.KObj(t.StorageClass))
+
+	if _, err := p.client.CoreV1().PersistentVolumeClaims(pvc.Namespace).Create(ctx, pvc, metav1.CreateOptions{}); err != nil {
+		return fmt.Errorf("failed to create canary persistentvolumeclaim: %v", err)
+	}
+
+	pod := p.newHelperPod(pvc.Name, pvc, t.Node.Name)
+	pod.Labels = map[string]string{labelCanary: "true"}
+
+	if _, err := p.client.CoreV1().Pods(pod.Namespace).Create(ctx, pod, metav1.CreateOptions{}); err != nil {
+		p.deleteCanary(ctx, pvc.Namespace, pvc.Name) // nolint: errcheck
+
+		return fmt.Errorf("failed to create canary pod: %v", err)
+	}
+
+	bound, err := p.waitBindPVC(ctx, pvc, timeouts.Bind)
+	if err != nil {
+		p.deleteCanary(ctx, pvc.Namespace, pvc.Name) // nolint: errcheck
+
+		return err
+	}
+
+	// A backend with the Retain policy would keep the volume of each canary run.
+	if t.StorageClass.ReclaimPolicy != nil && *t.StorageClass.ReclaimPolicy != corev1.PersistentVolumeReclaimDelete {
+		if err := p.deletePV(ctx, bound.Spec.VolumeName, bound); err != nil {
+			p.deleteCanary(ctx, pvc.Namespace, pvc.Name) // nolint: errcheck
+
+			return err
+		}
+	}
+
+	if err := p.deleteCanary(ctx, pvc.Namespace, pvc.Name); err != nil {
+		return err
+	}
+
+	return tools.PVWaitDelete(ctx, p.client, bound.Spec.VolumeName, timeouts.Delete)
+}
+
+func (p *HybridProvisioner) deleteCanary(ctx context.Context, namespace, name string) error {
+	if err := p.client.CoreV1().Pods(namespace).Delete(ctx, name, metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete canary pod: %v", err)
+	}
+
+	if err := p.client.CoreV1().PersistentVolumeClaims(namespace).Delete(ctx, name, metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete canary persistentvolumeclaim: %v", err)
+	}
+
+	return nil
+}
+
+// setCanaryResult exports the canary result, and emits an event on the backend StorageClass when its health changes.
+func (p *HybridProvisioner) setCanaryResult(t canaryTarget, duration time.Duration, err error) {
+	key := t.StorageClass.Name + "/" + t.Segment
+
+	p.mu.Lock()
+	healthy, known := p.canaries[key]
+	p.canaries[key] = err == nil
+	p.mu.Unlock()
+
+	canaryLastRun.WithLabelValues(t.StorageClass.Name, t.Segment).SetToCurrentTime()
+
+	if err != nil {
+		klog.ErrorS(err, "Canary provisioning failed", "node", klog.KObj(t.Node), "storageClass", klog.KObj(t.StorageClass), "segment", t.Segment)
+
+		canaryHealthy.WithLabelValues(t.StorageClass.Name, t.Segment).Set(0)
+
+		if !known || healthy {
+			p.recorder.Eventf(t.StorageClass, corev1.EventTypeWarning, "CanaryFailed",
+				"Canary provisioning on node %s failed: %v", t.Node.Name, err)
+		}
+
+		return
+	}
+
+	klog.V(4).InfoS("Canary provisioning succeeded", "node", klog.KObj(t.Node), "storageClass", klog.KObj(t.StorageClass), "segment", t.Segment, "duration", duration)
+
+	canaryHealthy.WithLabelValues(t.StorageClass.Name, t.Segment).Set(1)
+	canaryDuration.WithLabelValues(t.StorageClass.Name, t.Segment).Observe(duration.Seconds())
+
+	if known && !healthy {
+		p.recorder.Eventf(t.StorageClass, corev1.EventTypeNormal, "CanaryRecovered",
+			"Canary provisioning on node %s succeeded in %s", t.Node.Name, duration.Round(time.Second))
+	}
+}
@@ -0,0 +1,116 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provisioner
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/sergelogvinov/hybrid-csi-plugin/pkg/config"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestProvisionCanary(t *testing.T) {
+	tests := []struct {
+		name string
+		// retain is the backend with the Retain reclaim policy, and a canary volume which the fake backend binds
+		retain bool
+		// busy is the backend with no free provisioning slot
+		busy    bool
+		wantErr string
+	}{
+		{
+			// There is no PV controller to delete the volume, so the wait for the deletion times out.
+			name:    "backend with retain policy",
+			retain:  true,
+			wantErr: "to be deleted",
+		},
+		{
+			name:    "busy backend",
+			busy:    true,
+			wantErr: errCanaryBusy.Error(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			class := newTestStorageClass("backend", "backend.csi", map[string]string{
+				annBindTimeout:   "5s",
+				annDeleteTimeout: "100ms",
+				annMaxInFlight:   "1",
+			})
+
+			pv := newTestPV("pvc-canary", class.Name)
+
+			if tt.retain {
+				retain := corev1.PersistentVolumeReclaimRetain
+				class.ReclaimPolicy = &retain
+				pv.Spec.PersistentVolumeReclaimPolicy = retain
+			}
+
+			p, client := newTestProvisioner(t, class, pv)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			c := config.Default().Canary
+
+			if tt.busy {
+				p.acquireSlot(class.Name, newTestClaim("data", "1Gi"), 1)
+			}
+
+			go bindCanaryClaims(ctx, client, c.Namespace, pv.Name)
+
+			err := p.provisionCanary(ctx, c, canaryTarget{
+				StorageClass: class,
+				Policy:       &hybridPolicy{},
+				Segment:      "zone-a",
+				Node:         newTestNode("node-1", nil),
+			})
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("provisionCanary() error = %v, want %q", err, tt.wantErr)
+			}
+
+			if tt.retain {
+				got, err := client.CoreV1().PersistentVolumes().Get(ctx, pv.Name, metav1.GetOptions{})
+				if err != nil {
+					t.Fatalf("failed to get persistent volume: %v", err)
+				}
+
+				if got.Spec.PersistentVolumeReclaimPolicy != corev1.PersistentVolumeReclaimDelete {
+					t.Errorf("canary volume has the %s reclaim policy, want Delete", got.Spec.PersistentVolumeReclaimPolicy)
+				}
+
+				if got.Spec.ClaimRef == nil || !strings.HasPrefix(got.Spec.ClaimRef.Name, "hybrid-canary-") {
+					t.Errorf("canary volume is not bound to the canary claim: %+v", got.Spec.ClaimRef)
+				}
+			}
+
+			claims, err := client.CoreV1().PersistentVolumeClaims(c.Namespace).List(ctx, metav1.ListOptions{})
+			if err != nil {
+				t.Fatalf("failed to list persistent volume claims: %v", err)
+			}
+
+			if len(claims.Items) != 0 {
+				t.Errorf("got %d canary claims after the run, want 0", len(claims.Items))
+			}
+		})
+	}
+}
@@ -0,0 +1,161 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provisioner
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+const (
+	// Backend StorageClass annotations, describing what the backend can serve.
+	annAccessModes     = DriverName + "/access-modes"
+	annVolumeModes     = DriverName + "/volume-modes"
+	annMinSize         = DriverName + "/min-size"
+	annMaxSize         = DriverName + "/max-size"
+	annSizeGranularity = DriverName + "/size-granularity"
+)
+
+// backendCapabilities is a set of capabilities declared by the backend StorageClass or HybridStoragePolicy.
+// Empty fields mean no restriction.
+type backendCapabilities struct {
+	NodeSelector    labels.Selector
+	Features        []string
+	AccessModes     []corev1.PersistentVolumeAccessMode
+	VolumeModes     []corev1.PersistentVolumeMode
+	MinSize         *resource.Quantity
+	MaxSize         *resource.Quantity
+	SizeGranularity *resource.Quantity
+}
+
+// getBackendCapabilities parses the capabilities annotations of the backend StorageClass.
+func getBackendCapabilities(class *storagev1.StorageClass) (*backendCapabilities, error) {
+	caps := &backendCapabilities{
+		Features: splitList(class.Annotations[annFeatures]),
+	}
+
+	if v := class.Annotations[annAccessModes]; v != "" {
+		for _, mode := range splitList(v) {
+			switch m := corev1.PersistentVolumeAccessMode(mode); m {
+			case corev1.ReadWriteOnce, corev1.ReadOnlyMany, corev1.ReadWriteMany, corev1.ReadWriteOncePod:
+				caps.AccessModes = append(caps.AccessModes, m)
+			default:
+				return nil, fmt.Errorf("unknown access mode %q in annotation %s", mode, annAccessModes)
+			}
+		}
+	}
+
+	if v := class.Annotations[annVolumeModes]; v != "" {
+		for _, mode := range splitList(v) {
+			switch m := corev1.PersistentVolumeMode(mode); m {
+			case corev1.PersistentVolumeFilesystem, corev1.PersistentVolumeBlock:
+				caps.VolumeModes = append(caps.VolumeModes, m)
+			default:
+				return nil, fmt.Errorf("unknown volume mode %q in annotation %s", mode, annVolumeModes)
+			}
+		}
+	}
+
+	for ann, q := range map[string]**resource.Quantity{
+		annMinSize:         &caps.MinSize,
+		annMaxSize:         &caps.MaxSize,
+		annSizeGranularity: &caps.SizeGranularity,
+	} {
+		if v := class.Annotations[ann]; v != "" {
+			size, err := resource.ParseQuantity(v)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse annotation %s: %v", ann, err)
+			}
+
+			if size.Sign() <= 0 {
+				return nil, fmt.Errorf("annotation %s must be positive", ann)
+			}
+
+			*q = &size
+		}
+	}
+
+	return caps, nil
+}
+
+// check returns an error if the backend cannot serve the claim.
+func (c *backendCapabilities) check(claim *corev1.PersistentVolumeClaim) error {
+	if claim == nil {
+		return nil
+	}
+
+	if len(c.AccessModes) > 0 {
+		for _, mode := range claim.Spec.AccessModes {
+			if !slices.Contains(c.AccessModes, mode) {
+				return fmt.Errorf("access mode %s is not supported", mode)
+			}
+		}
+	}
+
+	if len(c.VolumeModes) > 0 {
+		mode := corev1.PersistentVolumeFilesystem
+		if claim.Spec.VolumeMode != nil {
+			mode = *claim.Spec.VolumeMode
+		}
+
+		if !slices.Contains(c.VolumeModes, mode) {
+			return fmt.Errorf("volume mode %s is not supported", mode)
+		}
+	}
+
+	request, ok := claim.Spec.Resources.Requests[corev1.ResourceStorage]
+	if !ok {
+		return nil
+	}
+
+	size := request.DeepCopy()
+
+	if c.SizeGranularity != nil {
+		step := c.SizeGranularity.Value()
+		if rem := size.Value() % step; rem != 0 {
+			size = *resource.NewQuantity(size.Value()+step-rem, request.Format)
+		}
+	}
+
+	if c.MinSize != nil && size.Cmp(*c.MinSize) < 0 {
+		return fmt.Errorf("requested size %s is less than minimum %s", size.String(), c.MinSize.String())
+	}
+
+	if c.MaxSize != nil && size.Cmp(*c.MaxSize) > 0 {
+		return fmt.Errorf("requested size %s is greater than maximum %s", size.String(), c.MaxSize.String())
+	}
+
+	return nil
+}
+
+func splitList(s string) []string {
+	var res []string
+
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			res = append(res, v)
+		}
+	}
+
+	return res
+}
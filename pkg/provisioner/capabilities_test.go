@@ -0,0 +1,160 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provisioner
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestGetBackendCapabilities(t *testing.T) {
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		wantErr     bool
+	}{
+		{
+			name: "no annotations",
+		},
+		{
+			name: "valid annotations",
+			annotations: map[string]string{
+				annAccessModes:     "ReadWriteOnce, ReadWriteOncePod",
+				annVolumeModes:     "Filesystem",
+				annMinSize:         "1Gi",
+				annMaxSize:         "1Ti",
+				annSizeGranularity: "1Gi",
+			},
+		},
+		{
+			name:        "unknown access mode",
+			annotations: map[string]string{annAccessModes: "ReadWriteSometimes"},
+			wantErr:     true,
+		},
+		{
+			name:        "unknown volume mode",
+			annotations: map[string]string{annVolumeModes: "Object"},
+			wantErr:     true,
+		},
+		{
+			name:        "invalid size",
+			annotations: map[string]string{annMinSize: "one"},
+			wantErr:     true,
+		},
+		{
+			name:        "zero size",
+			annotations: map[string]string{annSizeGranularity: "0"},
+			wantErr:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := getBackendCapabilities(newTestStorageClass("backend", "csi.example.com", tt.annotations))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("getBackendCapabilities() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestBackendCapabilitiesCheck(t *testing.T) {
+	block := corev1.PersistentVolumeBlock
+
+	blockClaim := newTestClaim("block", "1Gi", corev1.ReadWriteOnce)
+	blockClaim.Spec.VolumeMode = &block
+
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		claim       *corev1.PersistentVolumeClaim
+		wantErr     bool
+	}{
+		{
+			name:  "no restrictions",
+			claim: newTestClaim("pvc", "10Gi", corev1.ReadWriteMany),
+		},
+		{
+			name:  "nil claim",
+			claim: nil,
+		},
+		{
+			name:        "supported access mode",
+			annotations: map[string]string{annAccessModes: "ReadWriteOnce,ReadOnlyMany"},
+			claim:       newTestClaim("pvc", "1Gi", corev1.ReadWriteOnce),
+		},
+		{
+			name:        "unsupported access mode",
+			annotations: map[string]string{annAccessModes: "ReadWriteOnce"},
+			claim:       newTestClaim("pvc", "1Gi", corev1.ReadWriteOnce, corev1.ReadWriteMany),
+			wantErr:     true,
+		},
+		{
+			name:        "filesystem is the default volume mode",
+			annotations: map[string]string{annVolumeModes: "Filesystem"},
+			claim:       newTestClaim("pvc", "1Gi", corev1.ReadWriteOnce),
+		},
+		{
+			name:        "unsupported volume mode",
+			annotations: map[string]string{annVolumeModes: "Filesystem"},
+			claim:       blockClaim,
+			wantErr:     true,
+		},
+		{
+			name:        "size in range",
+			annotations: map[string]string{annMinSize: "1Gi", annMaxSize: "10Gi"},
+			claim:       newTestClaim("pvc", "10Gi", corev1.ReadWriteOnce),
+		},
+		{
+			name:        "size less than minimum",
+			annotations: map[string]string{annMinSize: "1Gi"},
+			claim:       newTestClaim("pvc", "512Mi", corev1.ReadWriteOnce),
+			wantErr:     true,
+		},
+		{
+			name:        "size greater than maximum",
+			annotations: map[string]string{annMaxSize: "10Gi"},
+			claim:       newTestClaim("pvc", "11Gi", corev1.ReadWriteOnce),
+			wantErr:     true,
+		},
+		{
+			name:        "size rounded up to the granularity exceeds maximum",
+			annotations: map[string]string{annSizeGranularity: "4Gi", annMaxSize: "10Gi"},
+			claim:       newTestClaim("pvc", "9Gi", corev1.ReadWriteOnce),
+			wantErr:     true,
+		},
+		{
+			name:        "size rounded up to the granularity reaches minimum",
+			annotations: map[string]string{annSizeGranularity: "1Gi", annMinSize: "1Gi"},
+			claim:       newTestClaim("pvc", "100Mi", corev1.ReadWriteOnce),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			caps, err := getBackendCapabilities(newTestStorageClass("backend", "csi.example.com", tt.annotations))
+			if err != nil {
+				t.Fatalf("getBackendCapabilities() error = %v", err)
+			}
+
+			if err := caps.check(tt.claim); (err != nil) != tt.wantErr {
+				t.Fatalf("check() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
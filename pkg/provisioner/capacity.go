@@ -0,0 +1,337 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provisioner
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"slices"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/wait"
+	storagelistersv1 "k8s.io/client-go/listers/storage/v1"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// CSIStorageCapacity labels, the same as the ones of the external-provisioner.
+	labelCapacityDriverName = "csi.storage.k8s.io/drivername"
+	labelCapacityManagedBy  = "csi.storage.k8s.io/managed-by"
+
+	capacityManagedBy = "hybrid-csi-provisioner"
+)
+
+// RunCapacityPublisher periodically publishes the CSIStorageCapacity objects of the hybrid StorageClasses in the namespace.
+// The capacities of the backend StorageClasses accessible from the same nodes are aggregated:
+// the capacity is the sum, and the maximum volume size is the largest one.
+func (p *HybridProvisioner) RunCapacityPublisher(
+	ctx context.Context,
+	capacityLister storagelistersv1.CSIStorageCapacityLister,
+	namespace string,
+	interval time.Duration,
+) {
+	wait.UntilWithContext(ctx, func(ctx context.Context) {
+		if err := p.publishCapacities(ctx, capacityLister, namespace); err != nil {
+			klog.ErrorS(err, "Failed to publish storage capacities")
+		}
+	}, interval)
+}
+
+func (p *HybridProvisioner) publishCapacities(ctx context.Context, capacityLister storagelistersv1.CSIStorageCapacityLister, namespace string) error {
+	desired, err := p.getHybridCapacities(capacityLister, namespace)
+	if err != nil {
+		return err
+	}
+
+	selector := labels.SelectorFromSet(labels.Set{
+		labelCapacityDriverName: DriverName,
+		labelCapacityManagedBy:  capacityManagedBy,
+	})
+
+	existing, err := capacityLister.CSIStorageCapacities(namespace).List(selector)
+	if err != nil {
+		return fmt.Errorf("failed to list CSIStorageCapacities: %v", err)
+	}
+
+	for _, c := range existing {
+		want, ok := desired[c.Name]
+		if !ok {
+			klog.V(4).InfoS("Deleting storage capacity", "capacity", klog.KObj(c))
+
+			if err := p.client.StorageV1().CSIStorageCapacities(namespace).Delete(ctx, c.Name, metav1.DeleteOptions{}); err != nil && !errors.IsNotFound(err) {
+				return fmt.Errorf("failed to delete CSIStorageCapacity: %v", err)
+			}
+
+			continue
+		}
+
+		delete(desired, c.Name)
+
+		if apiequality.Semantic.DeepEqual(c.Capacity, want.Capacity) && apiequality.Semantic.DeepEqual(c.MaximumVolumeSize, want.MaximumVolumeSize) {
+			continue
+		}
+
+		update := c.DeepCopy()
+		update.Capacity = want.Capacity
+		update.MaximumVolumeSize = want.MaximumVolumeSize
+
+		klog.V(4).InfoS("Updating storage capacity", "capacity", klog.KObj(c), "storageClass", c.StorageClassName, "size", update.Capacity)
+
+		if _, err := p.client.StorageV1().CSIStorageCapacities(namespace).Update(ctx, update, metav1.UpdateOptions{}); err != nil {
+			return fmt.Errorf("failed to update CSIStorageCapacity: %v", err)
+		}
+	}
+
+	for _, c := range desired {
+		klog.V(4).InfoS("Creating storage capacity", "capacity", klog.KObj(c), "storageClass", c.StorageClassName, "size", c.Capacity)
+
+		if _, err := p.client.StorageV1().CSIStorageCapacities(namespace).Create(ctx, c, metav1.CreateOptions{}); err != nil {
+			return fmt.Errorf("failed to create CSIStorageCapacity: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// getHybridCapacities returns the desired CSIStorageCapacity objects of the hybrid StorageClasses by name.
+// The backends publish their capacities with their own topology keys, so the capacities are resolved to the nodes,
+// and the nodes with the same backend capacities are published as one segment. The backends without published capacities
+// are skipped, the nodes which only they serve get no capacity. The backends in maintenance or with an open circuit breaker
+// are skipped as well.
+func (p *HybridProvisioner) getHybridCapacities(capacityLister storagelistersv1.CSIStorageCapacityLister, namespace string) (map[string]*storagev1.CSIStorageCapacity, error) {
+	capacities, err := capacityLister.List(labels.Everything())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list CSIStorageCapacities: %v", err)
+	}
+
+	backends := map[string][]*storagev1.CSIStorageCapacity{}
+	for _, c := range capacities {
+		backends[c.StorageClassName] = append(backends[c.StorageClassName], c)
+	}
+
+	classes, err := p.scLister.List(labels.Everything())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list storage classes: %v", err)
+	}
+
+	nodes, err := p.nodeLister.List(labels.Everything())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list nodes: %v", err)
+	}
+
+	res := map[string]*storagev1.CSIStorageCapacity{}
+
+	for _, class := range classes {
+		if class.Provisioner != DriverName {
+			continue
+		}
+
+		policy, err := p.getHybridPolicy(class)
+		if err != nil {
+			klog.V(4).InfoS("Skipping storage capacity of hybrid storage class", "storageClass", klog.KObj(class), "reason", err.Error())
+
+			continue
+		}
+
+		var backendCapacities []*storagev1.CSIStorageCapacity
+
+		for _, name := range policy.StorageClasses {
+			backend, err := p.scLister.Get(name)
+			if err != nil {
+				klog.V(4).InfoS("Backend of hybrid storage class not found", "storageClass", klog.KObj(class), "backend", name)
+
+				continue
+			}
+
+			// The backends which the provisioning skips do not add their capacity.
+			if err := p.checkBackendAvailable(backend); err != nil {
+				klog.V(4).InfoS("Skipping storage capacity of unavailable backend", "storageClass", klog.KObj(class), "backend", name, "reason", err.Error())
+
+				continue
+			}
+
+			if len(backends[name]) == 0 {
+				klog.V(4).InfoS("Backend of hybrid storage class has no storage capacity", "storageClass", klog.KObj(class), "backend", name)
+
+				continue
+			}
+
+			backendCapacities = append(backendCapacities, backends[name]...)
+		}
+
+		for _, seg := range getCapacitySegments(backendCapacities, nodes) {
+			agg := seg.aggregate()
+			agg.Name = capacityName(class.Name, metav1.FormatLabelSelector(agg.NodeTopology))
+			agg.Namespace = namespace
+			agg.Labels = map[string]string{
+				labelCapacityDriverName: DriverName,
+				labelCapacityManagedBy:  capacityManagedBy,
+			}
+			agg.StorageClassName = class.Name
+
+			res[agg.Name] = agg
+		}
+	}
+
+	return res, nil
+}
+
+// capacitySegment is a set of nodes with the same backend capacities.
+type capacitySegment struct {
+	Capacities []*storagev1.CSIStorageCapacity
+	Nodes      []*corev1.Node
+	// Topology is the node topology of the capacities, if they have the same one and it selects exactly the nodes.
+	Topology *metav1.LabelSelector
+}
+
+// getCapacitySegments groups the nodes by the backend capacities which are accessible from them.
+func getCapacitySegments(capacities []*storagev1.CSIStorageCapacity, nodes []*corev1.Node) []*capacitySegment {
+	segments := map[string]*capacitySegment{}
+	topologyNodes := map[string]int{}
+
+	for _, node := range nodes {
+		var accessible []*storagev1.CSIStorageCapacity
+
+		topologies := sets.New[string]()
+
+		for _, c := range capacities {
+			// A nil topology selects no nodes, and an empty one selects all nodes.
+			selector, err := metav1.LabelSelectorAsSelector(c.NodeTopology)
+			if err != nil || !selector.Matches(labels.Set(node.Labels)) {
+				continue
+			}
+
+			accessible = append(accessible, c)
+			topologies.Insert(metav1.FormatLabelSelector(c.NodeTopology))
+		}
+
+		for t := range topologies {
+			topologyNodes[t]++
+		}
+
+		if len(accessible) == 0 {
+			continue
+		}
+
+		keys := make([]string, 0, len(accessible))
+		for _, c := range accessible {
+			keys = append(keys, c.Namespace+"/"+c.Name)
+		}
+
+		key := strings.Join(keys, ",")
+
+		seg, ok := segments[key]
+		if !ok {
+			seg = &capacitySegment{Capacities: accessible}
+			segments[key] = seg
+		}
+
+		seg.Nodes = append(seg.Nodes, node)
+	}
+
+	res := make([]*capacitySegment, 0, len(segments))
+
+	for _, seg := range segments {
+		topology := metav1.FormatLabelSelector(seg.Capacities[0].NodeTopology)
+
+		if topologyNodes[topology] == len(seg.Nodes) && !slices.ContainsFunc(seg.Capacities, func(c *storagev1.CSIStorageCapacity) bool {
+			return metav1.FormatLabelSelector(c.NodeTopology) != topology
+		}) {
+			seg.Topology = seg.Capacities[0].NodeTopology.DeepCopy()
+		} else {
+			seg.Topology = hostnameSelector(seg.Nodes)
+		}
+
+		res = append(res, seg)
+	}
+
+	return res
+}
+
+// aggregate returns the capacity of the segment: the sum of the backend capacities, and the largest maximum volume size.
+// The overlapping capacities of the same backend are not summed, the largest one is used.
+func (seg *capacitySegment) aggregate() *storagev1.CSIStorageCapacity {
+	agg := &storagev1.CSIStorageCapacity{
+		NodeTopology:      seg.Topology,
+		Capacity:          resource.NewQuantity(0, resource.BinarySI),
+		MaximumVolumeSize: resource.NewQuantity(0, resource.BinarySI),
+	}
+
+	backends := map[string]resource.Quantity{}
+
+	for _, c := range seg.Capacities {
+		if c.Capacity != nil {
+			if q, ok := backends[c.StorageClassName]; !ok || c.Capacity.Cmp(q) > 0 {
+				backends[c.StorageClassName] = c.Capacity.DeepCopy()
+			}
+		}
+
+		size := c.MaximumVolumeSize
+		if size == nil {
+			size = c.Capacity
+		}
+
+		if size != nil && size.Cmp(*agg.MaximumVolumeSize) > 0 {
+			maxSize := size.DeepCopy()
+			agg.MaximumVolumeSize = &maxSize
+		}
+	}
+
+	for _, q := range backends {
+		agg.Capacity.Add(q)
+	}
+
+	return agg
+}
+
+// hostnameSelector returns the node topology which selects the nodes by their hostname label.
+func hostnameSelector(nodes []*corev1.Node) *metav1.LabelSelector {
+	hostnames := sets.New[string]()
+
+	for _, node := range nodes {
+		hostname, ok := node.Labels[corev1.LabelHostname]
+		if !ok {
+			hostname = node.Name
+		}
+
+		hostnames.Insert(hostname)
+	}
+
+	return &metav1.LabelSelector{
+		MatchExpressions: []metav1.LabelSelectorRequirement{
+			{Key: corev1.LabelHostname, Operator: metav1.LabelSelectorOpIn, Values: sets.List(hostnames)},
+		},
+	}
+}
+
+// capacityName returns the CSIStorageCapacity name of the hybrid StorageClass and node topology.
+func capacityName(storageClass, topology string) string {
+	h := fnv.New64a()
+	h.Write([]byte(storageClass + "/" + topology)) // nolint: errcheck
+
+	return fmt.Sprintf("hybrid-%016x", h.Sum64())
+}
@@ -0,0 +1,170 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provisioner
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/informers"
+)
+
+func TestGetHybridCapacities(t *testing.T) {
+	zoneA := map[string]string{corev1.LabelTopologyZone: "zone-a"}
+	zoneB := map[string]string{corev1.LabelTopologyZone: "zone-b"}
+
+	tests := []struct {
+		name       string
+		capacities []*storagev1.CSIStorageCapacity
+		// maintenance and open are the backends in maintenance and with an open circuit breaker
+		maintenance []string
+		open        []string
+		// want is the capacity of the nodes by the hostname
+		want map[string]string
+	}{
+		{
+			name: "backends with different topology keys",
+			capacities: []*storagev1.CSIStorageCapacity{
+				newTestCapacity("local-1", "local", "10Gi", map[string]string{"local.csi/node": "node-1"}),
+				newTestCapacity("local-2", "local", "20Gi", map[string]string{"local.csi/node": "node-2"}),
+				newTestCapacity("zonal-a", "zonal", "100Gi", zoneA),
+			},
+			want: map[string]string{"node-1": "110Gi", "node-2": "120Gi", "node-3": "100Gi"},
+		},
+		{
+			name: "backend without capacities",
+			capacities: []*storagev1.CSIStorageCapacity{
+				newTestCapacity("zonal-a", "zonal", "100Gi", zoneA),
+				newTestCapacity("zonal-b", "zonal", "50Gi", zoneB),
+			},
+			want: map[string]string{"node-1": "100Gi", "node-2": "100Gi", "node-3": "100Gi", "node-4": "50Gi"},
+		},
+		{
+			name: "overlapping capacities of the same backend",
+			capacities: []*storagev1.CSIStorageCapacity{
+				newTestCapacity("zonal-a", "zonal", "100Gi", zoneA),
+				newTestCapacity("zonal-all", "zonal", "10Gi", map[string]string{}),
+			},
+			want: map[string]string{"node-1": "100Gi", "node-2": "100Gi", "node-3": "100Gi", "node-4": "10Gi"},
+		},
+		{
+			name: "backend in maintenance",
+			capacities: []*storagev1.CSIStorageCapacity{
+				newTestCapacity("local-1", "local", "10Gi", map[string]string{"local.csi/node": "node-1"}),
+				newTestCapacity("zonal-a", "zonal", "100Gi", zoneA),
+			},
+			maintenance: []string{"local"},
+			want:        map[string]string{"node-1": "100Gi", "node-2": "100Gi", "node-3": "100Gi"},
+		},
+		{
+			name: "backend with open circuit breaker",
+			capacities: []*storagev1.CSIStorageCapacity{
+				newTestCapacity("local-1", "local", "10Gi", map[string]string{"local.csi/node": "node-1"}),
+				newTestCapacity("zonal-a", "zonal", "100Gi", zoneA),
+			},
+			open: []string{"zonal"},
+			want: map[string]string{"node-1": "10Gi"},
+		},
+		{
+			name: "no backend capacities",
+			want: map[string]string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			objects := []runtime.Object{
+				newTestStorageClass("hybrid", DriverName, nil),
+				newTestStorageClass("local", "local.csi", nil),
+				newTestStorageClass("zonal", "zonal.csi", nil),
+				newTestNode("node-1", map[string]string{corev1.LabelHostname: "node-1", corev1.LabelTopologyZone: "zone-a", "local.csi/node": "node-1"}),
+				newTestNode("node-2", map[string]string{corev1.LabelHostname: "node-2", corev1.LabelTopologyZone: "zone-a", "local.csi/node": "node-2"}),
+				newTestNode("node-3", map[string]string{corev1.LabelHostname: "node-3", corev1.LabelTopologyZone: "zone-a"}),
+				newTestNode("node-4", map[string]string{corev1.LabelHostname: "node-4", corev1.LabelTopologyZone: "zone-b"}),
+			}
+			objects[0].(*storagev1.StorageClass).Parameters = map[string]string{"storageClasses": "local,zonal"}
+
+			for _, obj := range objects {
+				if class, ok := obj.(*storagev1.StorageClass); ok && slices.Contains(tt.maintenance, class.Name) {
+					class.Annotations = map[string]string{annMaintenance: "true"}
+				}
+			}
+
+			for _, c := range tt.capacities {
+				objects = append(objects, c)
+			}
+
+			p, client := newTestProvisioner(t, objects...)
+
+			for _, name := range tt.open {
+				p.health[name] = &backendHealth{Failures: 1, OpenUntil: time.Now().Add(time.Minute)}
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			factory := informers.NewSharedInformerFactory(client, 0)
+			capacityLister := factory.Storage().V1().CSIStorageCapacities().Lister()
+
+			factory.Start(ctx.Done())
+			factory.WaitForCacheSync(ctx.Done())
+
+			capacities, err := p.getHybridCapacities(capacityLister, "kube-system")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			got := map[string]string{}
+
+			for _, c := range capacities {
+				if c.StorageClassName != "hybrid" {
+					t.Errorf("capacity of storage class %s, want hybrid", c.StorageClassName)
+				}
+
+				selector, err := metav1.LabelSelectorAsSelector(c.NodeTopology)
+				if err != nil {
+					t.Fatalf("invalid node topology: %v", err)
+				}
+
+				nodes, _ := p.nodeLister.List(selector) // nolint: errcheck
+				for _, node := range nodes {
+					if prev, ok := got[node.Name]; ok {
+						t.Errorf("node %s has the capacities %s and %s", node.Name, prev, c.Capacity.String())
+					}
+
+					got[node.Name] = c.Capacity.String()
+				}
+			}
+
+			if len(got) != len(tt.want) {
+				t.Errorf("got capacities of %d nodes, want %d: %v", len(got), len(tt.want), got)
+			}
+
+			for node, want := range tt.want {
+				if got[node] != want {
+					t.Errorf("node %s: got capacity %q, want %q", node, got[node], want)
+				}
+			}
+		})
+	}
+}
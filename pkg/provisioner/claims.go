@@ -0,0 +1,52 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provisioner
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+)
+
+// AddClaimHandlers forgets the in-memory provisioning state of the deleted claims.
+func (p *HybridProvisioner) AddClaimHandlers(claimInformer cache.SharedIndexInformer) error {
+	if _, err := claimInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		DeleteFunc: func(obj any) {
+			if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+
+			if claim, ok := obj.(*corev1.PersistentVolumeClaim); ok {
+				p.onClaimDeleted(claim)
+			}
+		},
+	}); err != nil {
+		return fmt.Errorf("failed to add PersistentVolumeClaim event handler: %v", err)
+	}
+
+	return nil
+}
+
+func (p *HybridProvisioner) onClaimDeleted(claim *corev1.PersistentVolumeClaim) {
+	klog.V(5).InfoS("Forget deleted persistent volume claim", "PVC", klog.KObj(claim))
+
+	p.resetExcludedStorageClasses(claim.UID)
+	p.forgetSlots(claim.UID)
+	p.forgetRejection(claim.UID)
+}
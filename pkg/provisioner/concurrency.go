@@ -0,0 +1,227 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provisioner
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// annMaxInFlight is the backend StorageClass annotation with the limit of concurrent in-flight provisions.
+const annMaxInFlight = DriverName + "/max-in-flight"
+
+// queueEntryTTL is the time after which a waiting claim which has not retried is removed from the queue.
+// It is longer than the maximum retry backoff of the provision controller (1000s),
+// so a waiting claim does not lose its position between two retries.
+const queueEntryTTL = 30 * time.Minute
+
+// backendQueue limits the in-flight provisions of a backend StorageClass.
+// The claims over the limit wait in the queue and are served in arrival order,
+// the claims at the head of the queue are requeued when a slot is released.
+type backendQueue struct {
+	limit    int
+	inFlight map[types.UID]struct{}
+	waiting  []types.UID
+	entries  map[types.UID]queueEntry
+}
+
+type queueEntry struct {
+	claim    types.NamespacedName
+	lastSeen time.Time
+}
+
+// getMaxInFlight returns the limit of concurrent in-flight provisions of the backend StorageClass, 0 means no limit.
+func (p *HybridProvisioner) getMaxInFlight(class *storagev1.StorageClass) (int, error) {
+	v, ok := class.Annotations[annMaxInFlight]
+	if !ok {
+		return p.config.Get().Concurrency.MaxInFlight, nil
+	}
+
+	limit, err := strconv.Atoi(v)
+	if err != nil || limit < 0 {
+		return 0, fmt.Errorf("annotation %s of storage class %s must be a non-negative integer", annMaxInFlight, class.Name)
+	}
+
+	return limit, nil
+}
+
+// acquireSlot takes a provisioning slot of the backend StorageClass for the claim.
+// If no slot is free, the claim keeps its position in the queue, which is returned.
+func (p *HybridProvisioner) acquireSlot(storageClass string, pvc *corev1.PersistentVolumeClaim, limit int) (int, bool) {
+	claim := pvc.UID
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	// The claim may have been queued for another backend by a previous attempt.
+	for name, q := range p.queues {
+		if name != storageClass {
+			q.dequeue(claim)
+			backendQueueDepth.WithLabelValues(name).Set(float64(len(q.waiting)))
+		}
+	}
+
+	q := p.getQueue(storageClass)
+	q.limit = limit
+
+	defer func() {
+		backendQueueDepth.WithLabelValues(storageClass).Set(float64(len(q.waiting)))
+		backendInFlight.WithLabelValues(storageClass).Set(float64(len(q.inFlight)))
+	}()
+
+	if _, ok := q.inFlight[claim]; ok {
+		return 0, true
+	}
+
+	now := time.Now()
+	q.expire(now.Add(-queueEntryTTL))
+
+	if !slices.Contains(q.waiting, claim) {
+		q.waiting = append(q.waiting, claim)
+	}
+
+	q.entries[claim] = queueEntry{
+		claim:    types.NamespacedName{Namespace: pvc.Namespace, Name: pvc.Name},
+		lastSeen: now,
+	}
+
+	pos := slices.Index(q.waiting, claim)
+	if limit > 0 && pos >= limit-len(q.inFlight) {
+		return pos + 1, false
+	}
+
+	q.dequeue(claim)
+	q.inFlight[claim] = struct{}{}
+
+	return 0, true
+}
+
+// tryAcquireSlot takes a free provisioning slot of the backend StorageClass, without joining the queue.
+// The waiting claims keep their priority, the slot is not taken if any claim is waiting.
+func (p *HybridProvisioner) tryAcquireSlot(storageClass string, id types.UID, limit int) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	q := p.getQueue(storageClass)
+	q.limit = limit
+
+	q.expire(time.Now().Add(-queueEntryTTL))
+
+	if len(q.waiting) > 0 || (limit > 0 && len(q.inFlight) >= limit) {
+		return false
+	}
+
+	q.inFlight[id] = struct{}{}
+	backendInFlight.WithLabelValues(storageClass).Set(float64(len(q.inFlight)))
+
+	return true
+}
+
+// releaseSlot frees the provisioning slot of the backend StorageClass taken by the claim,
+// and requeues the claims at the head of the queue, which can take the free slots.
+// Otherwise they would wait for the next retry of the provision controller, up to its maximum backoff.
+func (p *HybridProvisioner) releaseSlot(ctx context.Context, storageClass string, claim types.UID) {
+	p.mu.Lock()
+
+	var next []types.NamespacedName
+
+	// The slot may have been released early, before waiting for the deletion of a rejected volume.
+	if q, ok := p.queues[storageClass]; ok && q.release(claim) {
+		backendInFlight.WithLabelValues(storageClass).Set(float64(len(q.inFlight)))
+
+		q.expire(time.Now().Add(-queueEntryTTL))
+
+		n := len(q.waiting)
+		if q.limit > 0 {
+			n = min(n, max(q.limit-len(q.inFlight), 0))
+		}
+
+		for _, c := range q.waiting[:n] {
+			next = append(next, q.entries[c].claim)
+		}
+	}
+
+	p.mu.Unlock()
+
+	for _, c := range next {
+		p.requeueClaim(ctx, c, fmt.Sprintf("slot of storage class %s released", storageClass))
+	}
+}
+
+// forgetSlots removes the claim from the queues and frees its slots.
+func (p *HybridProvisioner) forgetSlots(claim types.UID) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for name, q := range p.queues {
+		q.dequeue(claim)
+		delete(q.inFlight, claim)
+
+		backendQueueDepth.WithLabelValues(name).Set(float64(len(q.waiting)))
+		backendInFlight.WithLabelValues(name).Set(float64(len(q.inFlight)))
+	}
+}
+
+// getQueue returns the queue of the backend StorageClass, the caller must hold the lock.
+func (p *HybridProvisioner) getQueue(storageClass string) *backendQueue {
+	q, ok := p.queues[storageClass]
+	if !ok {
+		q = &backendQueue{
+			inFlight: map[types.UID]struct{}{},
+			entries:  map[types.UID]queueEntry{},
+		}
+		p.queues[storageClass] = q
+	}
+
+	return q
+}
+
+// release frees the slot of the claim, it returns false if the claim does not hold a slot.
+func (q *backendQueue) release(claim types.UID) bool {
+	if _, ok := q.inFlight[claim]; !ok {
+		return false
+	}
+
+	delete(q.inFlight, claim)
+
+	return true
+}
+
+func (q *backendQueue) dequeue(claim types.UID) {
+	q.waiting = slices.DeleteFunc(q.waiting, func(c types.UID) bool { return c == claim })
+	delete(q.entries, claim)
+}
+
+// expire removes the claims which have not retried since the deadline, they were deleted or provisioned elsewhere.
+func (q *backendQueue) expire(deadline time.Time) {
+	q.waiting = slices.DeleteFunc(q.waiting, func(c types.UID) bool {
+		if q.entries[c].lastSeen.Before(deadline) {
+			delete(q.entries, c)
+
+			return true
+		}
+
+		return false
+	})
+}
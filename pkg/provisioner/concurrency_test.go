@@ -0,0 +1,165 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provisioner
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestAcquireSlot(t *testing.T) {
+	// slotStep acquires the slot of the claim, after releasing or forgetting the slot of another claim.
+	type slotStep struct {
+		release      string
+		forget       string
+		storageClass string
+		claim        string
+		wantOK       bool
+		wantPos      int
+	}
+
+	tests := []struct {
+		name  string
+		limit int
+		steps []slotStep
+	}{
+		{
+			name:  "claims wait in order",
+			limit: 1,
+			steps: []slotStep{
+				{storageClass: "backend", claim: "first", wantOK: true},
+				{storageClass: "backend", claim: "second", wantPos: 1},
+				{storageClass: "backend", claim: "third", wantPos: 2},
+				// A retry of the claim in flight keeps its slot.
+				{storageClass: "backend", claim: "first", wantOK: true},
+				// The third claim retries first, but the free slot belongs to the head of the queue.
+				{release: "first", storageClass: "backend", claim: "third", wantPos: 2},
+				{storageClass: "backend", claim: "second", wantOK: true},
+				// The claim moves to the queue of another backend.
+				{storageClass: "other", claim: "third", wantOK: true},
+				{storageClass: "backend", claim: "fourth", wantPos: 1},
+			},
+		},
+		{
+			name: "no limit",
+			steps: []slotStep{
+				{storageClass: "backend", claim: "first", wantOK: true},
+				{storageClass: "backend", claim: "second", wantOK: true},
+				{storageClass: "backend", claim: "third", wantOK: true},
+			},
+		},
+		{
+			name:  "deleted claim in flight",
+			limit: 1,
+			steps: []slotStep{
+				{storageClass: "backend", claim: "first", wantOK: true},
+				{storageClass: "backend", claim: "second", wantPos: 1},
+				{forget: "first", storageClass: "backend", claim: "second", wantOK: true},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, _ := newTestProvisioner(t)
+
+			for i, step := range tt.steps {
+				if step.release != "" {
+					p.releaseSlot(context.Background(), "backend", newTestClaim(step.release, "1Gi").UID)
+				}
+
+				if step.forget != "" {
+					p.forgetSlots(newTestClaim(step.forget, "1Gi").UID)
+				}
+
+				pos, ok := p.acquireSlot(step.storageClass, newTestClaim(step.claim, "1Gi"), tt.limit)
+				if ok != step.wantOK || (!ok && pos != step.wantPos) {
+					t.Errorf("step %d, claim %s: got position %d, ok %v, want position %d, ok %v", i, step.claim, pos, ok, step.wantPos, step.wantOK)
+				}
+			}
+		})
+	}
+}
+
+func TestReleaseSlot(t *testing.T) {
+	tests := []struct {
+		name     string
+		limit    int
+		releases int
+		// requeued are the waiting claims which get the free slot
+		requeued []string
+	}{
+		{
+			name:     "head of the queue is requeued",
+			limit:    1,
+			releases: 1,
+			requeued: []string{"second"},
+		},
+		{
+			// The second release of the same slot does not requeue a waiting claim again.
+			name:     "slot released twice",
+			limit:    2,
+			releases: 2,
+			requeued: []string{"third"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			first := newTestClaim("first", "1Gi")
+			second := newTestClaim("second", "1Gi")
+			third := newTestClaim("third", "1Gi")
+
+			p, client := newTestProvisioner(t, first, second, third)
+			ctx := context.Background()
+
+			p.acquireSlot("backend", first, tt.limit)
+			p.acquireSlot("backend", second, tt.limit)
+			p.acquireSlot("backend", third, tt.limit)
+
+			for range tt.releases {
+				p.releaseSlot(ctx, "backend", first.UID)
+			}
+
+			patches := 0
+
+			for _, action := range client.Actions() {
+				if action.Matches("patch", "persistentvolumeclaims") {
+					patches++
+				}
+			}
+
+			if patches != len(tt.requeued) {
+				t.Errorf("got %d requeues, want %d", patches, len(tt.requeued))
+			}
+
+			for _, name := range []string{"first", "second", "third"} {
+				claim, err := client.CoreV1().PersistentVolumeClaims("default").Get(ctx, name, metav1.GetOptions{})
+				if err != nil {
+					t.Fatalf("failed to get claim: %v", err)
+				}
+
+				if _, ok := claim.Annotations[annRequeuedAt]; ok != slices.Contains(tt.requeued, name) {
+					t.Errorf("claim %s: requeued %v, want %v", name, ok, slices.Contains(tt.requeued, name))
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,61 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provisioner
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/klog/v2"
+)
+
+// stickyStorageClasses keeps only the backend StorageClass of the first sibling volume,
+// if the consistency policy is sticky. The other storage classes are returned as rejected.
+func (p *HybridProvisioner) stickyStorageClasses(
+	classes []*storagev1.StorageClass,
+	claim *corev1.PersistentVolumeClaim,
+	policy *hybridPolicy,
+) ([]*storagev1.StorageClass, []candidate) {
+	if policy.ConsistencyPolicy != consistencyPolicySticky || claim == nil {
+		return classes, nil
+	}
+
+	volumes := p.getSiblingVolumes(claim)
+	if len(volumes) == 0 {
+		return classes, nil
+	}
+
+	sticky := volumes[0].Spec.StorageClassName
+
+	klog.V(4).InfoS("sticky storage class is used", "PVC", klog.KObj(claim), "storageClass", sticky, "PV", volumes[0].Name)
+
+	var (
+		res      []*storagev1.StorageClass
+		rejected []candidate
+	)
+
+	for _, class := range classes {
+		if class.Name == sticky {
+			res = append(res, class)
+		} else {
+			rejected = append(rejected, candidate{Name: class.Name, Reason: fmt.Sprintf("sibling volumes use storage class %s", sticky)})
+		}
+	}
+
+	return res, rejected
+}
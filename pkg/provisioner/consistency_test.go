@@ -0,0 +1,120 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provisioner
+
+import (
+	"slices"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestGetClaimGroup(t *testing.T) {
+	isController := true
+
+	owned := newTestClaim("data", "1Gi")
+	owned.OwnerReferences = []metav1.OwnerReference{{Kind: "ReplicaSet", Name: "rs", UID: "owner-uid", Controller: &isController}}
+
+	tests := []struct {
+		name  string
+		claim *corev1.PersistentVolumeClaim
+		want  string
+	}{
+		{name: "controller owner", claim: owned, want: "owner-uid"},
+		{name: "statefulset claim", claim: newTestClaim("data-web-12", "1Gi"), want: "data-web"},
+		{name: "standalone claim", claim: newTestClaim("data", "1Gi"), want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := getClaimGroup(tt.claim); got != tt.want {
+				t.Errorf("getClaimGroup() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStickyStorageClasses(t *testing.T) {
+	p, _ := newTestProvisioner(t,
+		newTestBoundClaim("data-web-1", "hybrid", "pv-1", 200),
+		newTestBoundClaim("data-web-0", "hybrid", "pv-0", 100),
+		newTestBoundClaim("data-web-9", "other", "pv-9", 50),
+		newTestPV("pv-0", "b"),
+		newTestPV("pv-1", "a"),
+		newTestPV("pv-9", "c"),
+	)
+
+	hybrid := "hybrid"
+
+	claim := newTestClaim("data-web-2", "1Gi", corev1.ReadWriteOnce)
+	claim.Spec.StorageClassName = &hybrid
+
+	tests := []struct {
+		name        string
+		consistency string
+		claim       *corev1.PersistentVolumeClaim
+		want        []string
+		rejected    []string
+	}{
+		{
+			name:        "none keeps all backends",
+			consistency: consistencyPolicyNone,
+			claim:       claim,
+			want:        []string{"a", "b", "c"},
+		},
+		{
+			name:        "sticky keeps the backend of the first sibling",
+			consistency: consistencyPolicySticky,
+			claim:       claim,
+			want:        []string{"b"},
+			rejected:    []string{"a", "c"},
+		},
+		{
+			name:        "sticky without siblings keeps all backends",
+			consistency: consistencyPolicySticky,
+			claim:       newTestClaim("standalone", "1Gi", corev1.ReadWriteOnce),
+			want:        []string{"a", "b", "c"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			classes := []*storagev1.StorageClass{
+				newTestStorageClass("a", "csi.a.com", nil),
+				newTestStorageClass("b", "csi.b.com", nil),
+				newTestStorageClass("c", "csi.c.com", nil),
+			}
+
+			res, rejected := p.stickyStorageClasses(classes, tt.claim, &hybridPolicy{ConsistencyPolicy: tt.consistency})
+
+			if got := storageClassNames(res); !slices.Equal(got, tt.want) {
+				t.Errorf("stickyStorageClasses() = %v, want %v", got, tt.want)
+			}
+
+			var got []string
+			for _, c := range rejected {
+				got = append(got, c.Name)
+			}
+
+			if !slices.Equal(got, tt.rejected) {
+				t.Errorf("rejected = %v, want %v", got, tt.rejected)
+			}
+		})
+	}
+}
@@ -0,0 +1,72 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provisioner
+
+import (
+	"fmt"
+	"slices"
+
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+)
+
+// BackendSelection is the backend StorageClass a hybrid claim would get on a node.
+type BackendSelection struct {
+	// StorageClass is the name of the backend StorageClass.
+	StorageClass string
+	// Rank is the position of the backend in the list of backends, 0 is the highest priority.
+	Rank int
+	// Backends is the number of backends of the hybrid StorageClass.
+	Backends int
+}
+
+// IsHybridStorageClass returns true if the StorageClass is provisioned by the hybrid provisioner.
+func IsHybridStorageClass(class *storagev1.StorageClass) bool {
+	return class.Provisioner == DriverName
+}
+
+// EvaluateNode returns the backend StorageClass which would be selected for the claim of the hybrid StorageClass on the node,
+// using the same checks as the provisioning, or an error if no backend can serve the claim.
+// It has no side effects: the spread policy is not applied, and no events or metrics are emitted.
+// The circuit breaker and failover exclusions are the in-memory state of the provisioning instance,
+// they are empty on the instance of the scheduler extender.
+func (p *HybridProvisioner) EvaluateNode(claim *corev1.PersistentVolumeClaim, class *storagev1.StorageClass, node *corev1.Node) (*BackendSelection, error) {
+	policy, err := p.getHybridPolicy(class)
+	if err != nil {
+		return nil, err
+	}
+
+	policy = policy.without(p.getExcludedStorageClasses(claim.UID, node.Name))
+
+	static := *policy
+	static.SpreadPolicy = spreadPolicyNone
+
+	classes, rejected, err := p.getStorageClassesFromNode(node, claim, &static)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(classes) == 0 {
+		return nil, fmt.Errorf("no matching storage class found: %s", formatCandidates(rejected))
+	}
+
+	return &BackendSelection{
+		StorageClass: classes[0].Name,
+		Rank:         slices.Index(policy.StorageClasses, classes[0].Name),
+		Backends:     len(policy.StorageClasses),
+	}, nil
+}
@@ -0,0 +1,76 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provisioner
+
+import (
+	"slices"
+
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+)
+
+const (
+	// annFeatures is the backend StorageClass annotation with the list of features the backend provides.
+	annFeatures = DriverName + "/features"
+
+	// PersistentVolumeClaim annotations with the list of features the volume requires or prefers.
+	annRequiredFeatures  = DriverName + "/required-features"
+	annPreferredFeatures = DriverName + "/preferred-features"
+)
+
+// getClaimFeatures returns the required and preferred features of the claim.
+func getClaimFeatures(claim *corev1.PersistentVolumeClaim) (required, preferred []string) {
+	if claim == nil {
+		return nil, nil
+	}
+
+	return splitList(claim.Annotations[annRequiredFeatures]), splitList(claim.Annotations[annPreferredFeatures])
+}
+
+// missingFeatures returns the list of required features which are not in features.
+func missingFeatures(features, required []string) []string {
+	var missing []string
+
+	for _, f := range required {
+		if !slices.Contains(features, f) {
+			missing = append(missing, f)
+		}
+	}
+
+	return missing
+}
+
+// sortByPreferredFeatures sorts the storage classes by the number of preferred features they provide,
+// keeping the original order for storage classes with the same number.
+func sortByPreferredFeatures(classes []*storagev1.StorageClass, preferred []string, policy *hybridPolicy) {
+	if len(preferred) == 0 {
+		return
+	}
+
+	score := func(class *storagev1.StorageClass) int {
+		caps, err := policy.getCapabilities(class)
+		if err != nil {
+			return 0
+		}
+
+		return len(preferred) - len(missingFeatures(caps.Features, preferred))
+	}
+
+	slices.SortStableFunc(classes, func(a, b *storagev1.StorageClass) int {
+		return score(b) - score(a)
+	})
+}
@@ -0,0 +1,110 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provisioner
+
+import (
+	"slices"
+	"testing"
+
+	storagev1 "k8s.io/api/storage/v1"
+)
+
+func TestMissingFeatures(t *testing.T) {
+	tests := []struct {
+		name     string
+		features []string
+		required []string
+		want     []string
+	}{
+		{
+			name:     "nothing required",
+			features: []string{"ssd"},
+		},
+		{
+			name:     "all provided",
+			features: []string{"ssd", "encrypted", "snapshot"},
+			required: []string{"encrypted", "ssd"},
+		},
+		{
+			name:     "some missing",
+			features: []string{"ssd"},
+			required: []string{"ssd", "encrypted", "replicated"},
+			want:     []string{"encrypted", "replicated"},
+		},
+		{
+			name:     "no features",
+			required: []string{"ssd"},
+			want:     []string{"ssd"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := missingFeatures(tt.features, tt.required); !slices.Equal(got, tt.want) {
+				t.Errorf("missingFeatures() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSortByPreferredFeatures(t *testing.T) {
+	newClass := func(name, features string) *storagev1.StorageClass {
+		return newTestStorageClass(name, "csi.example.com", map[string]string{annFeatures: features})
+	}
+
+	tests := []struct {
+		name      string
+		preferred []string
+		want      []string
+	}{
+		{
+			name: "no preferences keep the order",
+			want: []string{"hdd", "ssd", "nvme"},
+		},
+		{
+			name:      "single preference",
+			preferred: []string{"ssd"},
+			want:      []string{"ssd", "nvme", "hdd"},
+		},
+		{
+			name:      "more matches first",
+			preferred: []string{"ssd", "encrypted"},
+			want:      []string{"nvme", "ssd", "hdd"},
+		},
+		{
+			name:      "unknown preference keeps the order",
+			preferred: []string{"tape"},
+			want:      []string{"hdd", "ssd", "nvme"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			classes := []*storagev1.StorageClass{
+				newClass("hdd", "replicated"),
+				newClass("ssd", "ssd"),
+				newClass("nvme", "ssd,encrypted"),
+			}
+
+			sortByPreferredFeatures(classes, tt.preferred, &hybridPolicy{})
+
+			if got := storageClassNames(classes); !slices.Equal(got, tt.want) {
+				t.Errorf("sortByPreferredFeatures() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
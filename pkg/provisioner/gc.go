@@ -0,0 +1,131 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provisioner
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/klog/v2"
+)
+
+// labelHelper marks the intermediate objects created during the provisioning.
+const labelHelper = DriverName + "/helper"
+
+// RunGarbageCollector periodically deletes the helper pods and intermediate PersistentVolumeClaims
+// left by failed provisioning. The settings are read from the configuration on each run.
+func (p *HybridProvisioner) RunGarbageCollector(ctx context.Context) {
+	for {
+		gc := p.config.Get().GarbageCollection
+
+		if gc.Enabled {
+			p.collectGarbage(ctx, gc.MaxAge.Duration)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(gc.Interval.Duration):
+		}
+	}
+}
+
+// collectGarbage deletes the helper objects older than maxAge, except the objects of the volumes being provisioned.
+// A provisioning can last longer than maxAge, the timeouts are set per backend StorageClass.
+func (p *HybridProvisioner) collectGarbage(ctx context.Context, maxAge time.Duration) {
+	selector := labels.SelectorFromSet(labels.Set{labelHelper: "true"})
+	deadline := time.Now().Add(-maxAge)
+
+	provisioning, err := p.getProvisioningVolumes()
+	if err != nil {
+		klog.ErrorS(err, "Failed to list the volumes being provisioned")
+
+		return
+	}
+
+	pods, err := p.client.CoreV1().Pods(metav1.NamespaceAll).List(ctx, metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		klog.ErrorS(err, "Failed to list helper pods")
+	} else {
+		for _, pod := range pods.Items {
+			if pod.CreationTimestamp.After(deadline) || pod.DeletionTimestamp != nil || usesClaims(&pod, provisioning) {
+				continue
+			}
+
+			klog.InfoS("Deleting stale helper pod", "pod", klog.KObj(&pod))
+
+			if err := p.client.CoreV1().Pods(pod.Namespace).Delete(ctx, pod.Name, metav1.DeleteOptions{}); err != nil && !errors.IsNotFound(err) {
+				klog.ErrorS(err, "Failed to delete helper pod", "pod", klog.KObj(&pod))
+			}
+		}
+	}
+
+	claims, err := p.claimLister.List(selector)
+	if err != nil {
+		klog.ErrorS(err, "Failed to list intermediate persistent volume claims")
+
+		return
+	}
+
+	for _, pvc := range claims {
+		if pvc.CreationTimestamp.After(deadline) || pvc.DeletionTimestamp != nil || provisioning[pvc.Name] {
+			continue
+		}
+
+		klog.InfoS("Deleting stale intermediate persistent volume claim", "PVC", klog.KObj(pvc), "phase", pvc.Status.Phase)
+
+		policy := metav1.DeletePropagationForeground
+		if err := p.client.CoreV1().PersistentVolumeClaims(pvc.Namespace).Delete(ctx, pvc.Name, metav1.DeleteOptions{PropagationPolicy: &policy}); err != nil && !errors.IsNotFound(err) {
+			klog.ErrorS(err, "Failed to delete intermediate persistent volume claim", "PVC", klog.KObj(pvc))
+		}
+	}
+}
+
+// getProvisioningVolumes returns the names of the volumes being provisioned, their claims wait on the selected node.
+// The provision controller names the volume of a claim pvc-<claim UID>, the intermediate claim is named after the volume.
+func (p *HybridProvisioner) getProvisioningVolumes() (map[string]bool, error) {
+	claims, err := p.claimLister.List(labels.Everything())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list persistentvolumeclaims: %v", err)
+	}
+
+	volumes := map[string]bool{}
+
+	for _, pvc := range claims {
+		if pvc.Spec.VolumeName == "" && pvc.Annotations[annSelectedNode] != "" {
+			volumes["pvc-"+string(pvc.UID)] = true
+		}
+	}
+
+	return volumes, nil
+}
+
+// usesClaims returns true if the pod mounts one of the claims.
+func usesClaims(pod *corev1.Pod, claims map[string]bool) bool {
+	for _, v := range pod.Spec.Volumes {
+		if v.PersistentVolumeClaim != nil && claims[v.PersistentVolumeClaim.ClaimName] {
+			return true
+		}
+	}
+
+	return false
+}
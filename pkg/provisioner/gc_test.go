@@ -0,0 +1,89 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provisioner
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestCollectGarbage(t *testing.T) {
+	created := metav1.NewTime(time.Now().Add(-2 * time.Hour))
+
+	waiting := newTestClaim("waiting", "1Gi")
+	waiting.Annotations = map[string]string{annSelectedNode: "node-1"}
+
+	newHelperClaim := func(name string) *corev1.PersistentVolumeClaim {
+		pvc := newTestClaim(name, "1Gi")
+		pvc.Labels = map[string]string{labelHelper: "true"}
+		pvc.CreationTimestamp = created
+
+		return pvc
+	}
+
+	newHelperPod := func(name, claim string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              name,
+				Namespace:         "default",
+				Labels:            map[string]string{labelHelper: "true"},
+				CreationTimestamp: created,
+			},
+			Spec: corev1.PodSpec{
+				Volumes: []corev1.Volume{{
+					Name:         "provisioner",
+					VolumeSource: corev1.VolumeSource{PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: claim}},
+				}},
+			},
+		}
+	}
+
+	inFlight := "pvc-" + string(waiting.UID)
+
+	p, client := newTestProvisioner(t,
+		waiting,
+		newHelperClaim(inFlight),
+		newHelperClaim("pvc-stale"),
+		newHelperPod("provisioner-"+inFlight, inFlight),
+		newHelperPod("provisioner-pvc-stale", "pvc-stale"),
+	)
+
+	ctx := context.Background()
+	p.collectGarbage(ctx, time.Hour)
+
+	for _, tt := range []struct {
+		name string
+		kept bool
+	}{
+		{name: inFlight, kept: true},
+		{name: "pvc-stale", kept: false},
+	} {
+		_, err := client.CoreV1().PersistentVolumeClaims("default").Get(ctx, tt.name, metav1.GetOptions{})
+		if kept := err == nil; kept != tt.kept {
+			t.Errorf("claim %s: kept %v, want %v", tt.name, kept, tt.kept)
+		}
+
+		_, err = client.CoreV1().Pods("default").Get(ctx, "provisioner-"+tt.name, metav1.GetOptions{})
+		if kept := err == nil; kept != tt.kept {
+			t.Errorf("pod provisioner-%s: kept %v, want %v", tt.name, kept, tt.kept)
+		}
+	}
+}
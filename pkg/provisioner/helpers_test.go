@@ -0,0 +1,224 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provisioner
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	hybridv1alpha1 "github.com/sergelogvinov/hybrid-csi-plugin/pkg/apis/hybrid/v1alpha1"
+	"github.com/sergelogvinov/hybrid-csi-plugin/pkg/config"
+
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/tools/cache"
+)
+
+// newTestProvisioner returns a provisioner with the listers synced from the fake clientset objects.
+func newTestProvisioner(t *testing.T, objects ...runtime.Object) (*HybridProvisioner, *fake.Clientset) {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	client := fake.NewClientset(objects...)
+	factory := informers.NewSharedInformerFactory(client, 0)
+
+	vaInformer := factory.Storage().V1().VolumeAttachments().Informer()
+	if err := AddVolumeAttachmentIndexers(vaInformer); err != nil {
+		t.Fatalf("failed to add indexers: %v", err)
+	}
+
+	cfg, err := config.NewStore("", nil)
+	if err != nil {
+		t.Fatalf("failed to create config store: %v", err)
+	}
+
+	p := NewProvisioner(ctx, client, nil, methodAnnotation, cfg,
+		factory.Storage().V1().CSIDrivers().Lister(),
+		factory.Storage().V1().StorageClasses().Lister(),
+		factory.Storage().V1().CSINodes().Lister(),
+		vaInformer.GetIndexer(),
+		factory.Core().V1().Nodes().Lister(),
+		factory.Core().V1().PersistentVolumeClaims().Lister(),
+		factory.Core().V1().PersistentVolumes().Lister(),
+		nil,
+	)
+
+	factory.Start(ctx.Done())
+
+	for typ, ok := range factory.WaitForCacheSync(ctx.Done()) {
+		if !ok {
+			t.Fatalf("failed to sync informer %v", typ)
+		}
+	}
+
+	return p, client
+}
+
+// newTestNode returns a node with the labels.
+func newTestNode(name string, labels map[string]string) *corev1.Node {
+	return &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels}}
+}
+
+// newTestCSINode returns a CSINode with the drivers registered.
+func newTestCSINode(name string, drivers ...string) *storagev1.CSINode {
+	csiNode := &storagev1.CSINode{ObjectMeta: metav1.ObjectMeta{Name: name}}
+
+	for _, d := range drivers {
+		csiNode.Spec.Drivers = append(csiNode.Spec.Drivers, storagev1.CSINodeDriver{Name: d, NodeID: name})
+	}
+
+	return csiNode
+}
+
+// newTestCSIDriver returns a CSIDriver with the default fields.
+func newTestCSIDriver(name string) *storagev1.CSIDriver {
+	return &storagev1.CSIDriver{ObjectMeta: metav1.ObjectMeta{Name: name}}
+}
+
+// newTestStorageClass returns a StorageClass of the provisioner with the annotations.
+func newTestStorageClass(name, provisioner string, annotations map[string]string) *storagev1.StorageClass {
+	return &storagev1.StorageClass{
+		ObjectMeta:  metav1.ObjectMeta{Name: name, Annotations: annotations},
+		Provisioner: provisioner,
+	}
+}
+
+// newTestClaim returns a claim in the default namespace, with the UID derived from the name.
+func newTestClaim(name, size string, modes ...corev1.PersistentVolumeAccessMode) *corev1.PersistentVolumeClaim {
+	return &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", UID: types.UID("uid-" + name)},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			AccessModes: modes,
+			Resources: corev1.VolumeResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse(size)},
+			},
+		},
+	}
+}
+
+// newTestBoundClaim returns a claim of the StorageClass bound to the volume, created at the unix time.
+func newTestBoundClaim(name, storageClass, volume string, created int64) *corev1.PersistentVolumeClaim {
+	claim := newTestClaim(name, "1Gi", corev1.ReadWriteOnce)
+	claim.CreationTimestamp = metav1.Unix(created, 0)
+	claim.Spec.StorageClassName = &storageClass
+	claim.Spec.VolumeName = volume
+
+	return claim
+}
+
+// newTestPV returns a volume of the StorageClass.
+func newTestPV(name, storageClass string) *corev1.PersistentVolume {
+	return &corev1.PersistentVolume{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec:       corev1.PersistentVolumeSpec{StorageClassName: storageClass},
+	}
+}
+
+// newTestCapacity returns a CSIStorageCapacity of the StorageClass in the kube-system namespace.
+func newTestCapacity(name, storageClass, capacity string, topology map[string]string) *storagev1.CSIStorageCapacity {
+	q := resource.MustParse(capacity)
+
+	return &storagev1.CSIStorageCapacity{
+		ObjectMeta:       metav1.ObjectMeta{Name: name, Namespace: "kube-system"},
+		StorageClassName: storageClass,
+		NodeTopology:     &metav1.LabelSelector{MatchLabels: topology},
+		Capacity:         &q,
+	}
+}
+
+// newTestPolicyLister returns a lister of the HybridStoragePolicy resources.
+func newTestPolicyLister(t *testing.T, policies ...*hybridv1alpha1.HybridStoragePolicy) cache.GenericLister {
+	t.Helper()
+
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+
+	for _, hsp := range policies {
+		obj, err := runtime.DefaultUnstructuredConverter.ToUnstructured(hsp)
+		if err != nil {
+			t.Fatalf("failed to convert HybridStoragePolicy: %v", err)
+		}
+
+		if err := indexer.Add(&unstructured.Unstructured{Object: obj}); err != nil {
+			t.Fatalf("failed to add HybridStoragePolicy: %v", err)
+		}
+	}
+
+	return cache.NewGenericLister(indexer, hybridv1alpha1.HybridStoragePolicyResource.GroupResource())
+}
+
+// newTestDynamicClient returns a dynamic client which serves the VolumePlacement resources.
+func newTestDynamicClient() *dynamicfake.FakeDynamicClient {
+	return dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{hybridv1alpha1.VolumePlacementResource: "VolumePlacementList"})
+}
+
+// bindCanaryClaims binds the canary claims to the volume, as the backend provisioner would do, until the context is done.
+func bindCanaryClaims(ctx context.Context, client *fake.Clientset, namespace, volume string) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(10 * time.Millisecond):
+		}
+
+		claims, err := client.CoreV1().PersistentVolumeClaims(namespace).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			continue
+		}
+
+		// The claim is updated on each iteration, the watch may start after the first update.
+		for _, claim := range claims.Items {
+			claim.Spec.VolumeName = volume
+			claim.Status.Phase = corev1.ClaimBound
+
+			client.CoreV1().PersistentVolumeClaims(namespace).Update(ctx, &claim, metav1.UpdateOptions{}) // nolint: errcheck
+		}
+	}
+}
+
+// storageClassNames returns the names of the StorageClasses in order.
+func storageClassNames(classes []*storagev1.StorageClass) []string {
+	names := make([]string, 0, len(classes))
+	for _, c := range classes {
+		names = append(names, c.Name)
+	}
+
+	return names
+}
+
+// zoneTerms returns a topology term which selects the zones.
+func zoneTerms(zones ...string) []corev1.TopologySelectorTerm {
+	return []corev1.TopologySelectorTerm{
+		{
+			MatchLabelExpressions: []corev1.TopologySelectorLabelRequirement{
+				{Key: corev1.LabelTopologyZone, Values: zones},
+			},
+		},
+	}
+}
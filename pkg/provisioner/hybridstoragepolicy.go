@@ -0,0 +1,107 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provisioner
+
+import (
+	"fmt"
+	"strings"
+
+	hybridv1alpha1 "github.com/sergelogvinov/hybrid-csi-plugin/pkg/apis/hybrid/v1alpha1"
+
+	storagev1 "k8s.io/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// getHybridStoragePolicy returns the provisioning policy defined by the HybridStoragePolicy resource.
+func (p *HybridProvisioner) getHybridStoragePolicy(class *storagev1.StorageClass, name string) (*hybridPolicy, error) {
+	if p.policyLister == nil {
+		return nil, fmt.Errorf("HybridStoragePolicy resources are disabled, %s parameter cannot be used", paramPolicy)
+	}
+
+	obj, err := p.policyLister.Get(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get HybridStoragePolicy %s: %v", name, err)
+	}
+
+	u, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return nil, fmt.Errorf("unexpected HybridStoragePolicy %s object type %T", name, obj)
+	}
+
+	hsp := &hybridv1alpha1.HybridStoragePolicy{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, hsp); err != nil {
+		return nil, fmt.Errorf("failed to convert HybridStoragePolicy %s: %v", name, err)
+	}
+
+	backends := make([]string, 0, len(hsp.Spec.Backends))
+	for _, b := range hsp.Spec.Backends {
+		backends = append(backends, b.StorageClassName)
+	}
+
+	params := map[string]string{
+		paramStorageClasses: strings.Join(backends, ","),
+	}
+
+	for param, v := range map[string]string{
+		paramSpreadPolicy:       hsp.Spec.SpreadPolicy,
+		paramConsistencyPolicy:  hsp.Spec.ConsistencyPolicy,
+		paramMountOptionsPolicy: hsp.Spec.MountOptionsPolicy,
+	} {
+		if v != "" {
+			params[param] = v
+		}
+	}
+
+	if hsp.Spec.Failover != nil && hsp.Spec.Failover.NodeAffinityMismatch != "" {
+		params[paramNodeAffinityMismatch] = hsp.Spec.Failover.NodeAffinityMismatch
+	}
+
+	policy, err := parseHybridPolicy(class, p.withDefaultParameters(params))
+	if err != nil {
+		return nil, fmt.Errorf("invalid HybridStoragePolicy %s: %v", name, err)
+	}
+
+	policy.Backends = make(map[string]*backendCapabilities, len(hsp.Spec.Backends))
+
+	for _, b := range hsp.Spec.Backends {
+		caps := &backendCapabilities{
+			Features:        b.Features,
+			AccessModes:     b.AccessModes,
+			VolumeModes:     b.VolumeModes,
+			MinSize:         b.MinSize,
+			MaxSize:         b.MaxSize,
+			SizeGranularity: b.SizeGranularity,
+		}
+
+		if b.NodeSelector != nil {
+			caps.NodeSelector, err = metav1.LabelSelectorAsSelector(b.NodeSelector)
+			if err != nil {
+				return nil, fmt.Errorf("invalid HybridStoragePolicy %s node selector of %s: %v", name, b.StorageClassName, err)
+			}
+		}
+
+		if caps.SizeGranularity != nil && caps.SizeGranularity.Sign() <= 0 {
+			return nil, fmt.Errorf("invalid HybridStoragePolicy %s size granularity of %s must be positive", name, b.StorageClassName)
+		}
+
+		policy.Backends[b.StorageClassName] = caps
+	}
+
+	return policy, nil
+}
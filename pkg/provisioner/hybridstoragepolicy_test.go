@@ -0,0 +1,120 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provisioner
+
+import (
+	"slices"
+	"testing"
+
+	hybridv1alpha1 "github.com/sergelogvinov/hybrid-csi-plugin/pkg/apis/hybrid/v1alpha1"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+func TestGetHybridStoragePolicy(t *testing.T) {
+	maxSize := resource.MustParse("100Gi")
+	zero := resource.MustParse("0")
+
+	policies := []*hybridv1alpha1.HybridStoragePolicy{
+		&hybridv1alpha1.HybridStoragePolicy{
+			ObjectMeta: metav1.ObjectMeta{Name: "fast-first"},
+			Spec: hybridv1alpha1.HybridStoragePolicySpec{
+				Backends: []hybridv1alpha1.HybridStoragePolicyBackend{
+					{
+						StorageClassName: "fast",
+						NodeSelector:     &metav1.LabelSelector{MatchLabels: map[string]string{"disk": "nvme"}},
+						Features:         []string{"ssd"},
+						AccessModes:      []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+						MaxSize:          &maxSize,
+					},
+					{StorageClassName: "slow"},
+				},
+				SpreadPolicy: spreadPolicyRoundRobin,
+				Failover:     &hybridv1alpha1.HybridStoragePolicyFailover{NodeAffinityMismatch: nodeAffinityMismatchFailover},
+			},
+		},
+		&hybridv1alpha1.HybridStoragePolicy{
+			ObjectMeta: metav1.ObjectMeta{Name: "invalid"},
+			Spec: hybridv1alpha1.HybridStoragePolicySpec{
+				Backends:          []hybridv1alpha1.HybridStoragePolicyBackend{{StorageClassName: "fast"}},
+				SpreadPolicy:      spreadPolicySpread,
+				ConsistencyPolicy: consistencyPolicySticky,
+			},
+		},
+		&hybridv1alpha1.HybridStoragePolicy{
+			ObjectMeta: metav1.ObjectMeta{Name: "zero-granularity"},
+			Spec: hybridv1alpha1.HybridStoragePolicySpec{
+				Backends: []hybridv1alpha1.HybridStoragePolicyBackend{{StorageClassName: "fast", SizeGranularity: &zero}},
+			},
+		},
+	}
+
+	p, _ := newTestProvisioner(t)
+	p.policyLister = newTestPolicyLister(t, policies...)
+
+	class := newTestStorageClass("hybrid", DriverName, nil)
+
+	policy, err := p.getHybridStoragePolicy(class, "fast-first")
+	if err != nil {
+		t.Fatalf("getHybridStoragePolicy() error = %v", err)
+	}
+
+	if !slices.Equal(policy.StorageClasses, []string{"fast", "slow"}) {
+		t.Errorf("storage classes = %v, want [fast slow]", policy.StorageClasses)
+	}
+
+	if policy.SpreadPolicy != spreadPolicyRoundRobin || policy.NodeAffinityMismatch != nodeAffinityMismatchFailover {
+		t.Errorf("policy = %+v, want roundRobin spread and failover", policy)
+	}
+
+	caps := policy.Backends["fast"]
+	if caps == nil || !slices.Equal(caps.Features, []string{"ssd"}) || caps.MaxSize.Cmp(maxSize) != 0 {
+		t.Fatalf("fast capabilities = %+v", caps)
+	}
+
+	if !caps.NodeSelector.Matches(labels.Set{"disk": "nvme"}) || caps.NodeSelector.Matches(labels.Set{"disk": "hdd"}) {
+		t.Errorf("fast node selector = %v", caps.NodeSelector)
+	}
+
+	tests := []struct {
+		name     string
+		policy   string
+		disabled bool
+	}{
+		{name: "invalid policy", policy: "invalid"},
+		{name: "zero size granularity", policy: "zero-granularity"},
+		{name: "missing policy", policy: "missing"},
+		{name: "disabled policies", policy: "fast-first", disabled: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, _ := newTestProvisioner(t)
+
+			if !tt.disabled {
+				p.policyLister = newTestPolicyLister(t, policies...)
+			}
+
+			if _, err := p.getHybridStoragePolicy(class, tt.policy); err == nil {
+				t.Errorf("getHybridStoragePolicy(%s) expected an error", tt.policy)
+			}
+		})
+	}
+}
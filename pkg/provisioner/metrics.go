@@ -0,0 +1,95 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provisioner
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const metricsNamespace = "hybrid_provisioner"
+
+var (
+	backendQueueDepth = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "backend_queue_depth",
+		Help:      "Number of claims waiting for a free provisioning slot of the backend StorageClass.",
+	}, []string{"storage_class"})
+
+	backendInFlight = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "backend_in_flight",
+		Help:      "Number of in-flight provisions of the backend StorageClass.",
+	}, []string{"storage_class"})
+
+	backendFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "backend_failures_total",
+		Help:      "Number of provisioning failures of the backend StorageClass.",
+	}, []string{"storage_class"})
+
+	backendCircuitOpen = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "backend_circuit_open",
+		Help:      "Whether the circuit breaker of the backend StorageClass is open after consecutive failures.",
+	}, []string{"storage_class"})
+
+	backendCircuitOpenUntil = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "backend_circuit_open_until_timestamp_seconds",
+		Help:      "Time until the circuit breaker of the backend StorageClass is open, 0 if it is closed.",
+	}, []string{"storage_class"})
+
+	backendMaintenance = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "backend_maintenance",
+		Help:      "Whether the backend StorageClass is in maintenance.",
+	}, []string{"storage_class"})
+
+	canaryHealthy = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "canary_healthy",
+		Help:      "Whether the last canary provisioning of the backend StorageClass in the topology segment succeeded.",
+	}, []string{"storage_class", "segment"})
+
+	canaryDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "canary_duration_seconds",
+		Help:      "End-to-end time of the successful canary provisioning and deletion.",
+		Buckets:   []float64{1, 5, 10, 30, 60, 120, 300, 600},
+	}, []string{"storage_class", "segment"})
+
+	canaryLastRun = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "canary_last_run_timestamp_seconds",
+		Help:      "Time of the last canary provisioning of the backend StorageClass in the topology segment.",
+	}, []string{"storage_class", "segment"})
+)
+
+// Collectors returns the metrics of the hybrid provisioner.
+func Collectors() []prometheus.Collector {
+	return []prometheus.Collector{
+		backendQueueDepth,
+		backendInFlight,
+		backendFailures,
+		backendCircuitOpen,
+		backendCircuitOpenUntil,
+		backendMaintenance,
+		canaryHealthy,
+		canaryDuration,
+		canaryLastRun,
+	}
+}
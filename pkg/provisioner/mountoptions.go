@@ -0,0 +1,61 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provisioner
+
+import (
+	"slices"
+	"strings"
+)
+
+// mergeMountOptions merges the mount options of the hybrid and backend StorageClasses according to the policy.
+//
+//   - hybrid: the hybrid options are used, if they are not empty.
+//   - backend: the backend options are used, if they are not empty.
+//   - union: all options are used, the backend option wins if both define the same option.
+func mergeMountOptions(policy string, hybrid, backend []string) []string {
+	switch policy {
+	case mountOptionsPolicyHybrid:
+		if len(hybrid) > 0 {
+			return hybrid
+		}
+
+		return backend
+	case mountOptionsPolicyBackend:
+		if len(backend) > 0 {
+			return backend
+		}
+
+		return hybrid
+	}
+
+	res := slices.Clone(backend)
+
+	for _, opt := range hybrid {
+		if !slices.ContainsFunc(res, func(o string) bool { return mountOptionName(o) == mountOptionName(opt) }) {
+			res = append(res, opt)
+		}
+	}
+
+	return res
+}
+
+// mountOptionName returns the name of the mount option, without the value.
+func mountOptionName(opt string) string {
+	name, _, _ := strings.Cut(opt, "=")
+
+	return name
+}
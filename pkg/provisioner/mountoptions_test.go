@@ -0,0 +1,85 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provisioner
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestMergeMountOptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  string
+		hybrid  []string
+		backend []string
+		want    []string
+	}{
+		{
+			name:    "union",
+			policy:  mountOptionsPolicyUnion,
+			hybrid:  []string{"noatime", "discard"},
+			backend: []string{"nodiratime"},
+			want:    []string{"nodiratime", "noatime", "discard"},
+		},
+		{
+			name:    "union backend value wins",
+			policy:  mountOptionsPolicyUnion,
+			hybrid:  []string{"commit=30", "noatime"},
+			backend: []string{"commit=60"},
+			want:    []string{"commit=60", "noatime"},
+		},
+		{
+			name:   "union without options",
+			policy: mountOptionsPolicyUnion,
+		},
+		{
+			name:    "hybrid",
+			policy:  mountOptionsPolicyHybrid,
+			hybrid:  []string{"noatime"},
+			backend: []string{"nodiratime"},
+			want:    []string{"noatime"},
+		},
+		{
+			name:    "hybrid falls back to backend",
+			policy:  mountOptionsPolicyHybrid,
+			backend: []string{"nodiratime"},
+			want:    []string{"nodiratime"},
+		},
+		{
+			name:    "backend",
+			policy:  mountOptionsPolicyBackend,
+			hybrid:  []string{"noatime"},
+			backend: []string{"nodiratime"},
+			want:    []string{"nodiratime"},
+		},
+		{
+			name:   "backend falls back to hybrid",
+			policy: mountOptionsPolicyBackend,
+			hybrid: []string{"noatime"},
+			want:   []string{"noatime"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := mergeMountOptions(tt.policy, tt.hybrid, tt.backend); !slices.Equal(got, tt.want) {
+				t.Errorf("mergeMountOptions() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,122 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provisioner
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	hybridcsi "github.com/sergelogvinov/hybrid-csi-plugin/pkg/csi"
+
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+)
+
+// LabelBackendValue is the value of the backend node labels.
+const LabelBackendValue = "true"
+
+// RunBackendNodeLabels keeps the backend node labels in sync with the drivers of the CSINode objects until the context is done.
+// The drivers register after the node, and deregister, at any time, so the labels are updated on each CSINode event.
+func (p *HybridProvisioner) RunBackendNodeLabels(ctx context.Context, csiNodeInformer cache.SharedIndexInformer) {
+	sync := func(obj any) {
+		if csiNode, ok := obj.(*storagev1.CSINode); ok {
+			if err := p.syncBackendNodeLabels(ctx, csiNode); err != nil {
+				klog.ErrorS(err, "Failed to update backend node labels", "node", csiNode.Name)
+			}
+		}
+	}
+
+	registration, err := csiNodeInformer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    sync,
+		UpdateFunc: func(_, obj any) { sync(obj) },
+	})
+	if err != nil {
+		klog.ErrorS(err, "Failed to add CSINode event handler")
+
+		return
+	}
+
+	<-ctx.Done()
+
+	csiNodeInformer.RemoveEventHandler(registration) // nolint: errcheck
+}
+
+// syncBackendNodeLabels patches the backend labels of the node, if they differ from the drivers of its CSINode.
+func (p *HybridProvisioner) syncBackendNodeLabels(ctx context.Context, csiNode *storagev1.CSINode) error {
+	node, err := p.nodeLister.Get(csiNode.Name)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return nil
+		}
+
+		return fmt.Errorf("failed to get node: %v", err)
+	}
+
+	want := getBackendNodeLabels(csiNode)
+	labels := map[string]any{}
+
+	for k := range node.Labels {
+		if _, ok := want[k]; !ok && strings.HasPrefix(k, hybridcsi.TopologyBackendPrefix) {
+			labels[k] = nil
+		}
+	}
+
+	for k, v := range want {
+		if node.Labels[k] != v {
+			labels[k] = v
+		}
+	}
+
+	if len(labels) == 0 {
+		return nil
+	}
+
+	patch, _ := json.Marshal(map[string]any{ // nolint: errcheck,errchkjson
+		"metadata": map[string]any{"labels": labels},
+	})
+
+	if _, err := p.client.CoreV1().Nodes().Patch(ctx, node.Name, types.MergePatchType, patch, metav1.PatchOptions{}); err != nil {
+		return fmt.Errorf("failed to patch node: %v", err)
+	}
+
+	klog.InfoS("Backend node labels updated", "node", klog.KObj(node), "labels", slices.Sorted(maps.Keys(want)))
+
+	return nil
+}
+
+// getBackendNodeLabels returns the backend labels of the drivers registered on the node.
+func getBackendNodeLabels(csiNode *storagev1.CSINode) map[string]string {
+	labels := map[string]string{}
+
+	for _, driver := range csiNode.Spec.Drivers {
+		if driver.Name == DriverName {
+			continue
+		}
+
+		labels[hybridcsi.TopologyBackendPrefix+driver.Name] = LabelBackendValue
+	}
+
+	return labels
+}
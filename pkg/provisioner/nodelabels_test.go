@@ -0,0 +1,93 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provisioner
+
+import (
+	"context"
+	"maps"
+	"testing"
+
+	hybridcsi "github.com/sergelogvinov/hybrid-csi-plugin/pkg/csi"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestSyncBackendNodeLabels(t *testing.T) {
+	tests := []struct {
+		name    string
+		labels  map[string]string
+		drivers []string
+		want    map[string]string
+		patched bool
+	}{
+		{
+			name:    "registered drivers",
+			labels:  map[string]string{"zone": "a"},
+			drivers: []string{DriverName, "a.csi", "b.csi"},
+			want:    map[string]string{"zone": "a", hybridcsi.TopologyBackendPrefix + "a.csi": "true", hybridcsi.TopologyBackendPrefix + "b.csi": "true"},
+			patched: true,
+		},
+		{
+			name:    "deregistered driver",
+			labels:  map[string]string{"zone": "a", hybridcsi.TopologyBackendPrefix + "a.csi": "true", hybridcsi.TopologyBackendPrefix + "b.csi": "true"},
+			drivers: []string{"b.csi"},
+			want:    map[string]string{"zone": "a", hybridcsi.TopologyBackendPrefix + "b.csi": "true"},
+			patched: true,
+		},
+		{
+			name:    "labels in sync",
+			labels:  map[string]string{hybridcsi.TopologyBackendPrefix + "a.csi": "true"},
+			drivers: []string{DriverName, "a.csi"},
+			want:    map[string]string{hybridcsi.TopologyBackendPrefix + "a.csi": "true"},
+			patched: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, client := newTestProvisioner(t, newTestNode("node-1", tt.labels))
+			ctx := context.Background()
+
+			client.ClearActions()
+
+			if err := p.syncBackendNodeLabels(ctx, newTestCSINode("node-1", tt.drivers...)); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if patched := len(client.Actions()) > 0; patched != tt.patched {
+				t.Errorf("node patched %v, want %v", patched, tt.patched)
+			}
+
+			node, err := client.CoreV1().Nodes().Get(ctx, "node-1", metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("failed to get node: %v", err)
+			}
+
+			if !maps.Equal(node.Labels, tt.want) {
+				t.Errorf("got labels %v, want %v", node.Labels, tt.want)
+			}
+		})
+	}
+}
+
+func TestSyncBackendNodeLabelsMissingNode(t *testing.T) {
+	p, _ := newTestProvisioner(t)
+
+	if err := p.syncBackendNodeLabels(context.Background(), newTestCSINode("node-1", "a.csi")); err != nil {
+		t.Errorf("unexpected error for a missing node: %v", err)
+	}
+}
@@ -0,0 +1,104 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provisioner
+
+import (
+	"fmt"
+	"hash/fnv"
+	"time"
+
+	hybridv1alpha1 "github.com/sergelogvinov/hybrid-csi-plugin/pkg/apis/hybrid/v1alpha1"
+
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+const (
+	// LabelStorageClass is the label of the hybrid PV with the hybrid StorageClass name.
+	LabelStorageClass = DriverName + "/storage-class"
+	// LabelBackendStorageClass is the label of the hybrid PV with the backend StorageClass name.
+	LabelBackendStorageClass = DriverName + "/backend-storage-class"
+	// LabelMethod is the label of the hybrid PV with the provisioning method.
+	LabelMethod = DriverName + "/method"
+
+	// AnnProvisionedAt is the annotation of the hybrid PV with the time of the provisioning decision.
+	AnnProvisionedAt = DriverName + "/provisioned-at"
+	// AnnCandidates is the annotation of the hybrid PV with the evaluated backend StorageClasses.
+	AnnCandidates = DriverName + "/candidates"
+	// AnnStorageClass is the annotation of the hybrid PV with the full hybrid StorageClass name.
+	AnnStorageClass = DriverName + "/storage-class"
+	// AnnBackendStorageClass is the annotation of the hybrid PV with the full backend StorageClass name.
+	AnnBackendStorageClass = DriverName + "/backend-storage-class"
+)
+
+// placement is the provisioning decision for the hybrid volume.
+type placement struct {
+	// Policy is the provisioning policy of the hybrid StorageClass.
+	Policy *hybridPolicy
+	// StorageClass is the selected backend StorageClass.
+	StorageClass *storagev1.StorageClass
+	// Method is the provisioning method.
+	Method string
+	// Timeouts is the timeouts and backoff of the selected backend StorageClass.
+	Timeouts *backendTimeouts
+	// Candidates is the list of evaluated backend StorageClasses, the selected one is the first.
+	Candidates []candidate
+	// Time is the time of the decision.
+	Time time.Time
+
+	// VolumeName is the name of the provisioned PV.
+	VolumeName string
+	// Timings is the list of the provisioning phase transitions.
+	Timings []hybridv1alpha1.VolumePlacementTiming
+
+	// resourceVersion is the resource version of the last written VolumePlacement.
+	resourceVersion string
+}
+
+// labels returns the provenance labels of the hybrid PV.
+func (pl *placement) labels() map[string]string {
+	return map[string]string{
+		LabelStorageClass:        labelValue(pl.Policy.Name),
+		LabelBackendStorageClass: labelValue(pl.StorageClass.Name),
+		LabelMethod:              pl.Method,
+	}
+}
+
+// annotations returns the provenance annotations of the hybrid PV.
+func (pl *placement) annotations() map[string]string {
+	return map[string]string{
+		AnnProvisionedAt:       pl.Time.UTC().Format(time.RFC3339),
+		AnnCandidates:          formatCandidates(pl.Candidates),
+		AnnStorageClass:        pl.Policy.Name,
+		AnnBackendStorageClass: pl.StorageClass.Name,
+	}
+}
+
+// labelValue returns the name as a valid label value. StorageClass names can be longer than a label value,
+// such names are truncated and suffixed by their hash.
+func labelValue(name string) string {
+	if len(name) <= validation.LabelValueMaxLength {
+		return name
+	}
+
+	h := fnv.New64a()
+	h.Write([]byte(name)) // nolint: errcheck
+
+	suffix := fmt.Sprintf("-%016x", h.Sum64())
+
+	return name[:validation.LabelValueMaxLength-len(suffix)] + suffix
+}
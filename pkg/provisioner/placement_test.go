@@ -0,0 +1,65 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provisioner
+
+import (
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+func TestPlacementLabels(t *testing.T) {
+	long := strings.Repeat("backend.storage.example.com-", 9)
+
+	tests := []struct {
+		name         string
+		storageClass string
+	}{
+		{name: "short name", storageClass: "backend"},
+		{name: "long name", storageClass: long},
+		{name: "long name with the same prefix", storageClass: long + "x"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pl := &placement{
+				Policy:       &hybridPolicy{Name: "hybrid"},
+				StorageClass: newTestStorageClass(tt.storageClass, "csi.example.com", nil),
+				Method:       methodAnnotation,
+			}
+
+			for k, v := range pl.labels() {
+				if errs := validation.IsValidLabelValue(v); len(errs) > 0 {
+					t.Errorf("label %s=%q is not valid: %v", k, v, errs)
+				}
+			}
+
+			if got := pl.labels()[LabelStorageClass]; got != "hybrid" {
+				t.Errorf("storage class label = %q, want hybrid", got)
+			}
+
+			if got := pl.annotations()[AnnBackendStorageClass]; got != tt.storageClass {
+				t.Errorf("backend annotation = %q, want the full name %q", got, tt.storageClass)
+			}
+		})
+	}
+
+	if labelValue(long) == labelValue(long+"x") {
+		t.Errorf("names with the same prefix have the same label value %q", labelValue(long))
+	}
+}
@@ -0,0 +1,204 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provisioner
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/sergelogvinov/hybrid-csi-plugin/pkg/config"
+
+	storagev1 "k8s.io/api/storage/v1"
+)
+
+const (
+	// Hybrid StorageClass parameters
+	paramPolicy               = "policy"
+	paramStorageClasses       = "storageClasses"
+	paramSpreadPolicy         = "spreadPolicy"
+	paramConsistencyPolicy    = "consistencyPolicy"
+	paramNodeAffinityMismatch = "nodeAffinityMismatch"
+	paramMountOptionsPolicy   = "mountOptionsPolicy"
+)
+
+const (
+	spreadPolicyNone       = "none"
+	spreadPolicySpread     = "spread"
+	spreadPolicyRoundRobin = "roundRobin"
+
+	consistencyPolicyNone   = "none"
+	consistencyPolicySticky = "sticky"
+
+	nodeAffinityMismatchFail     = "fail"
+	nodeAffinityMismatchFailover = "failover"
+
+	mountOptionsPolicyUnion   = "union"
+	mountOptionsPolicyHybrid  = "hybrid"
+	mountOptionsPolicyBackend = "backend"
+)
+
+// hybridPolicy is the provisioning policy of the hybrid StorageClass.
+type hybridPolicy struct {
+	// Name is the name of the hybrid StorageClass.
+	Name string
+	// StorageClasses is the list of backend StorageClasses, in order of priority.
+	StorageClasses []string
+	// SpreadPolicy defines how the volumes of the same workload are distributed across the backends.
+	SpreadPolicy string
+	// ConsistencyPolicy defines whether the volumes of the same workload must use the same backend.
+	ConsistencyPolicy string
+	// NodeAffinityMismatch defines what to do if the backend volume is not accessible from the selected node.
+	NodeAffinityMismatch string
+	// MountOptions is the list of mount options of the hybrid StorageClass.
+	MountOptions []string
+	// MountOptionsPolicy defines how the mount options of the hybrid and backend StorageClasses are merged.
+	MountOptionsPolicy string
+	// Backends is the capabilities of the backend StorageClasses declared by the policy,
+	// the backend StorageClass annotations are used for the missing ones.
+	Backends map[string]*backendCapabilities
+}
+
+// getHybridPolicy returns the provisioning policy of the hybrid StorageClass. It is defined by the StorageClass parameters
+// or by the HybridStoragePolicy resource referenced by the policy parameter.
+func (p *HybridProvisioner) getHybridPolicy(class *storagev1.StorageClass) (*hybridPolicy, error) {
+	if name, ok := class.Parameters[paramPolicy]; ok {
+		return p.getHybridStoragePolicy(class, name)
+	}
+
+	return parseHybridPolicy(class, p.withDefaultParameters(class.Parameters))
+}
+
+// withDefaultParameters returns the parameters completed by the default parameters of the configuration.
+func (p *HybridProvisioner) withDefaultParameters(params map[string]string) map[string]string {
+	defaults := p.config.Get().DefaultParameters
+	if len(defaults) == 0 {
+		return params
+	}
+
+	res := maps.Clone(defaults)
+	maps.Copy(res, params)
+
+	return res
+}
+
+// ValidateConfig checks the default parameters of the configuration.
+func ValidateConfig(cfg *config.Config) error {
+	params := map[string]string{paramStorageClasses: "default"}
+
+	for k, v := range cfg.DefaultParameters {
+		switch k {
+		case paramSpreadPolicy, paramConsistencyPolicy, paramNodeAffinityMismatch, paramMountOptionsPolicy:
+			params[k] = v
+		default:
+			return fmt.Errorf("unsupported default parameter %s", k)
+		}
+	}
+
+	if _, err := parseHybridPolicy(&storagev1.StorageClass{}, params); err != nil {
+		return fmt.Errorf("invalid default parameters: %v", err)
+	}
+
+	return nil
+}
+
+// parseHybridPolicy returns the provisioning policy defined by the parameters.
+func parseHybridPolicy(class *storagev1.StorageClass, params map[string]string) (*hybridPolicy, error) {
+	classes, ok := params[paramStorageClasses]
+	if !ok {
+		return nil, fmt.Errorf("%s parameter is required", paramStorageClasses)
+	}
+
+	policy := &hybridPolicy{
+		Name:                 class.Name,
+		StorageClasses:       splitList(classes),
+		SpreadPolicy:         spreadPolicyNone,
+		ConsistencyPolicy:    consistencyPolicyNone,
+		NodeAffinityMismatch: nodeAffinityMismatchFail,
+		MountOptions:         class.MountOptions,
+		MountOptionsPolicy:   mountOptionsPolicyUnion,
+	}
+
+	if len(policy.StorageClasses) == 0 {
+		return nil, fmt.Errorf("%s parameter is empty", paramStorageClasses)
+	}
+
+	if v, ok := params[paramSpreadPolicy]; ok {
+		switch v {
+		case spreadPolicyNone, spreadPolicySpread, spreadPolicyRoundRobin:
+			policy.SpreadPolicy = v
+		default:
+			return nil, fmt.Errorf("unknown %s parameter value %q", paramSpreadPolicy, v)
+		}
+	}
+
+	if v, ok := params[paramConsistencyPolicy]; ok {
+		switch v {
+		case consistencyPolicyNone, consistencyPolicySticky:
+			policy.ConsistencyPolicy = v
+		default:
+			return nil, fmt.Errorf("unknown %s parameter value %q", paramConsistencyPolicy, v)
+		}
+	}
+
+	if v, ok := params[paramNodeAffinityMismatch]; ok {
+		switch v {
+		case nodeAffinityMismatchFail, nodeAffinityMismatchFailover:
+			policy.NodeAffinityMismatch = v
+		default:
+			return nil, fmt.Errorf("unknown %s parameter value %q", paramNodeAffinityMismatch, v)
+		}
+	}
+
+	if v, ok := params[paramMountOptionsPolicy]; ok {
+		switch v {
+		case mountOptionsPolicyUnion, mountOptionsPolicyHybrid, mountOptionsPolicyBackend:
+			policy.MountOptionsPolicy = v
+		default:
+			return nil, fmt.Errorf("unknown %s parameter value %q", paramMountOptionsPolicy, v)
+		}
+	}
+
+	if policy.ConsistencyPolicy == consistencyPolicySticky && policy.SpreadPolicy != spreadPolicyNone {
+		return nil, fmt.Errorf("%s %q cannot be used with %s %q", paramConsistencyPolicy, policy.ConsistencyPolicy, paramSpreadPolicy, policy.SpreadPolicy)
+	}
+
+	return policy, nil
+}
+
+// without returns a copy of the policy without the specified backend StorageClasses.
+func (p *hybridPolicy) without(classes []string) *hybridPolicy {
+	if len(classes) == 0 {
+		return p
+	}
+
+	res := *p
+	res.StorageClasses = slices.DeleteFunc(slices.Clone(p.StorageClasses), func(c string) bool {
+		return slices.Contains(classes, c)
+	})
+
+	return &res
+}
+
+// getCapabilities returns the capabilities of the backend StorageClass.
+func (p *hybridPolicy) getCapabilities(class *storagev1.StorageClass) (*backendCapabilities, error) {
+	if caps, ok := p.Backends[class.Name]; ok {
+		return caps, nil
+	}
+
+	return getBackendCapabilities(class)
+}
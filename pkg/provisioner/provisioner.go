@@ -20,22 +20,31 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"slices"
 	"strings"
+	"sync"
 	"time"
 
 	controller "sigs.k8s.io/sig-storage-lib-external-provisioner/v10/controller"
 
+	hybridv1alpha1 "github.com/sergelogvinov/hybrid-csi-plugin/pkg/apis/hybrid/v1alpha1"
+	"github.com/sergelogvinov/hybrid-csi-plugin/pkg/config"
+
 	corev1 "k8s.io/api/core/v1"
 	storagev1 "k8s.io/api/storage/v1"
 	errors "k8s.io/apimachinery/pkg/api/errors"
-	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
 	corelisters "k8s.io/client-go/listers/core/v1"
 	storagelistersv1 "k8s.io/client-go/listers/storage/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/component-helpers/storage/volume"
 	"k8s.io/klog/v2"
 )
@@ -46,9 +55,6 @@ const (
 	// DriverVersion is the version of the CSI driver
 	DriverVersion = "0.1.0"
 
-	defaultCreateProvisionedPVRetryCount = 5
-	defaultCreateProvisionedPVInterval   = 10 * time.Second
-
 	annBetaStorageProvisioner = "volume.beta.kubernetes.io/storage-provisioner"
 	annStorageProvisioner     = "volume.kubernetes.io/storage-provisioner"
 	annSelectedNode           = "volume.kubernetes.io/selected-node"
@@ -62,28 +68,45 @@ const (
 
 // HybridProvisioner is a hybrid provisioner
 type HybridProvisioner struct {
-	client kubernetes.Interface
-	method string
-
-	backoff wait.Backoff
+	client        kubernetes.Interface
+	dynamicClient dynamic.Interface
+	method        string
+	config        *config.Store
+	recorder      record.EventRecorder
 
 	driverLister  storagelistersv1.CSIDriverLister
 	scLister      storagelistersv1.StorageClassLister
 	csiNodeLister storagelistersv1.CSINodeLister
+	vaIndexer     cache.Indexer
 	nodeLister    corelisters.NodeLister
 	claimLister   corelisters.PersistentVolumeClaimLister
+	pvLister      corelisters.PersistentVolumeLister
+	policyLister  cache.GenericLister
+
+	mu         sync.Mutex
+	roundRobin map[string]int
+	excluded   map[types.UID]*exclusion
+	queues     map[string]*backendQueue
+	rejections map[types.UID]*rejection
+	health     map[string]*backendHealth
+	canaries   map[string]bool
 }
 
 // NewProvisioner creates a new hybrid provisioner
 func NewProvisioner(
-	_ context.Context,
+	ctx context.Context,
 	client kubernetes.Interface,
+	dynamicClient dynamic.Interface,
 	method string,
+	cfg *config.Store,
 	driverLister storagelistersv1.CSIDriverLister,
 	scLister storagelistersv1.StorageClassLister,
 	csiNodeLister storagelistersv1.CSINodeLister,
+	vaIndexer cache.Indexer,
 	nodeLister corelisters.NodeLister,
 	claimLister corelisters.PersistentVolumeClaimLister,
+	pvLister corelisters.PersistentVolumeLister,
+	policyLister cache.GenericLister,
 ) *HybridProvisioner {
 	switch method {
 	case methodDefault, methodPod, methodAnnotation:
@@ -92,22 +115,32 @@ func NewProvisioner(
 		klog.Warningf("Unknown provisioner method, using %s", method)
 	}
 
-	p := &HybridProvisioner{
-		client: client,
+	broadcaster := record.NewBroadcaster(record.WithContext(ctx))
+	broadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: client.CoreV1().Events(metav1.NamespaceAll)})
 
-		method: method,
+	p := &HybridProvisioner{
+		client:        client,
+		dynamicClient: dynamicClient,
 
-		backoff: wait.Backoff{
-			Duration: defaultCreateProvisionedPVInterval,
-			Factor:   1, // linear backoff
-			Steps:    defaultCreateProvisionedPVRetryCount,
-		},
+		method:   method,
+		config:   cfg,
+		recorder: broadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: DriverName}),
 
 		driverLister:  driverLister,
 		scLister:      scLister,
 		csiNodeLister: csiNodeLister,
+		vaIndexer:     vaIndexer,
 		nodeLister:    nodeLister,
 		claimLister:   claimLister,
+		pvLister:      pvLister,
+		policyLister:  policyLister,
+
+		roundRobin: map[string]int{},
+		excluded:   map[types.UID]*exclusion{},
+		queues:     map[string]*backendQueue{},
+		rejections: map[types.UID]*rejection{},
+		health:     map[string]*backendHealth{},
+		canaries:   map[string]bool{},
 	}
 
 	return p
@@ -128,113 +161,123 @@ func (p *HybridProvisioner) Provision(ctx context.Context, opts controller.Provi
 		return nil, controller.ProvisioningFinished, fmt.Errorf("storageClass is required")
 	}
 
-	classes, ok := opts.StorageClass.Parameters["storageClasses"]
-	if !ok {
-		return nil, controller.ProvisioningFinished, fmt.Errorf("storageClasses parameter is required")
-	}
-
-	storageClass, err := p.getStorageClassFromNode(opts.SelectedNode, strings.Split(classes, ","))
+	policy, err := p.getHybridPolicy(opts.StorageClass)
 	if err != nil {
-		return nil, controller.ProvisioningReschedule, err
+		return nil, controller.ProvisioningFinished, err
 	}
 
-	var pv *corev1.PersistentVolume
+	p.forgetRejection(opts.PVC.UID)
 
-	switch p.method {
-	case "auto", "annotation":
-		pv, err = p.createPVbyAnnotation(ctx, opts, storageClass)
-		if err != nil {
-			return nil, controller.ProvisioningFinished, err
-		}
-	case "pod":
-		pv, err = p.createPVbyPOD(ctx, opts, storageClass)
-		if err != nil {
-			return nil, controller.ProvisioningFinished, err
-		}
+	policy = policy.without(p.getExcludedStorageClasses(opts.PVC.UID, opts.SelectedNode.Name))
+
+	pl := &placement{
+		Policy: policy,
+		Method: p.method,
+		Time:   time.Now(),
 	}
 
-	pv.ResourceVersion = ""
+	if pl.Method == methodDefault {
+		pl.Method = methodAnnotation
+	}
 
-	return pv, controller.ProvisioningFinished, nil
-}
+	p.recordPlacement(ctx, opts, pl, hybridv1alpha1.VolumePlacementSelecting, "")
+	p.updateBackendMetrics(policy.StorageClasses)
 
-// Delete removes the storage asset that was created by Provision backing the
-// given PV. Does not delete the PV object itself.
-func (p *HybridProvisioner) Delete(_ context.Context, pv *corev1.PersistentVolume) (err error) {
-	klog.V(4).InfoS("Delete: called", "pv", pv.Name)
+	storageClass, rejected, err := p.getStorageClassFromNode(opts.SelectedNode, opts.PVC, policy)
+	if err != nil {
+		pl.Candidates = rejected
+		p.recordPlacement(ctx, opts, pl, hybridv1alpha1.VolumePlacementFailed, err.Error())
 
-	return nil
-}
+		// The claim gets a new node, where the excluded backends may work.
+		p.resetExcludedStorageClasses(opts.PVC.UID)
+		p.recordRejection(opts.PVC, opts.SelectedNode.Name, policy, err.Error())
 
-// Get first matched StorageClass from the list of storage classes supported by the selected node
-func (p *HybridProvisioner) getStorageClassFromNode(selectedNode *corev1.Node, storageClasses []string) (*storagev1.StorageClass, error) {
-	selectedCSINode, err := p.csiNodeLister.Get(selectedNode.Name)
-	if err != nil {
-		return nil, fmt.Errorf("error getting CSINode for selected node %q: %v", selectedNode.Name, err)
+		return nil, controller.ProvisioningReschedule, err
 	}
 
-	if selectedCSINode == nil {
-		return nil, fmt.Errorf("CSINode for selected node %q not found", selectedNode.Name)
+	pl.StorageClass = storageClass
+	pl.Candidates = append([]candidate{{Name: storageClass.Name}}, rejected...)
+
+	pl.Timeouts, err = p.getBackendTimeouts(storageClass)
+	if err != nil {
+		p.recordPlacement(ctx, opts, pl, hybridv1alpha1.VolumePlacementFailed, err.Error())
+
+		return nil, controller.ProvisioningFinished, err
 	}
 
-	for _, storageClass := range storageClasses {
-		class, err := p.scLister.Get(storageClass)
-		if err != nil {
-			klog.V(4).InfoS("storage class is not found", "node", klog.KObj(selectedNode), "storageClass", storageClass)
+	limit, err := p.getMaxInFlight(storageClass)
+	if err != nil {
+		p.recordPlacement(ctx, opts, pl, hybridv1alpha1.VolumePlacementFailed, err.Error())
 
-			continue
-		}
+		return nil, controller.ProvisioningFinished, err
+	}
 
-		if len(class.AllowedTopologies) > 0 {
-			topologyKeys := getTopologyKeys(selectedCSINode, class.Provisioner)
+	if pos, ok := p.acquireSlot(storageClass.Name, opts.PVC, limit); !ok {
+		err = fmt.Errorf("storage class %s has reached the limit of %d in-flight provisions, the claim is waiting at position %d", storageClass.Name, limit, pos)
+		p.recordPlacement(ctx, opts, pl, hybridv1alpha1.VolumePlacementSelecting, err.Error())
 
-			selectedTopology, isMissingKey := getTopologyFromNode(selectedNode, topologyKeys)
-			if isMissingKey {
-				klog.V(5).InfoS("getTopologyFromNode key is missing", "node", klog.KObj(selectedNode), "storageClass", storageClass)
+		return nil, controller.ProvisioningInBackground, err
+	}
 
-				continue
-			}
+	defer p.releaseSlot(ctx, storageClass.Name, opts.PVC.UID)
 
-			allowedTopologiesFlatten := flatten(class.AllowedTopologies)
+	p.recordPlacement(ctx, opts, pl, hybridv1alpha1.VolumePlacementBinding, "")
 
-			found := false
+	var pv *corev1.PersistentVolume
 
-			for _, t := range allowedTopologiesFlatten {
-				if t.subset(selectedTopology) {
-					found = true
-					break
-				}
-			}
+	switch pl.Method {
+	case methodAnnotation:
+		pv, err = p.createPVbyAnnotation(ctx, opts, pl)
+	case methodPod:
+		pv, err = p.createPVbyPOD(ctx, opts, pl)
+	}
 
-			if !found {
-				klog.V(4).InfoS("topology is not in allowed", "node", klog.KObj(selectedNode), "storageClass", storageClass, "topology", selectedTopology)
+	if err != nil {
+		if isNodeAffinityError(err) {
+			if policy.NodeAffinityMismatch == nodeAffinityMismatchFailover {
+				klog.InfoS("Failover to the next storage class", "PVC", klog.KObj(opts.PVC), "storageClass", klog.KObj(storageClass))
 
-				continue
+				p.excludeStorageClass(opts.PVC.UID, opts.SelectedNode.Name, storageClass.Name)
 			}
+		} else if ctx.Err() == nil {
+			p.recordBackendFailure(storageClass.Name)
 		}
 
-		if driver, err := p.driverLister.Get(class.Provisioner); err != nil || driver == nil {
-			// Provisioner is not a CSI driver
-			return class, nil // nolint: nilerr
-		}
+		p.recordPlacement(ctx, opts, pl, hybridv1alpha1.VolumePlacementFailed, err.Error())
 
-		for _, driver := range selectedCSINode.Spec.Drivers {
-			if driver.Name == class.Provisioner {
-				return class, nil
-			}
-		}
+		return nil, controller.ProvisioningFinished, err
 	}
 
-	return nil, fmt.Errorf("no matching storage class found for selected node %q", selectedNode.Name)
+	p.recordBackendSuccess(storageClass.Name)
+
+	pl.VolumeName = pv.Name
+	p.recordPlacement(ctx, opts, pl, hybridv1alpha1.VolumePlacementBonded, "")
+
+	p.resetExcludedStorageClasses(opts.PVC.UID)
+
+	pv.ResourceVersion = ""
+
+	return pv, controller.ProvisioningFinished, nil
 }
 
-func (p *HybridProvisioner) createPVbyAnnotation(ctx context.Context, opts controller.ProvisionOptions, storageClass *storagev1.StorageClass) (pv *corev1.PersistentVolume, err error) {
+// Delete removes the storage asset that was created by Provision backing the
+// given PV. Does not delete the PV object itself.
+func (p *HybridProvisioner) Delete(_ context.Context, pv *corev1.PersistentVolume) (err error) {
+	klog.V(4).InfoS("Delete: called", "pv", pv.Name)
+
+	return nil
+}
+
+func (p *HybridProvisioner) createPVbyAnnotation(ctx context.Context, opts controller.ProvisionOptions, pl *placement) (pv *corev1.PersistentVolume, err error) {
+	storageClass := pl.StorageClass
+
 	klog.V(4).InfoS("createPVusingAnnotation: called", "pvc", klog.KObj(opts.PVC), "node", klog.KObj(opts.SelectedNode), "storageClass", klog.KObj(storageClass))
 
 	pvcreq := &corev1.PersistentVolumeClaim{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      opts.PVName,
 			Namespace: opts.PVC.Namespace,
+			Labels:    map[string]string{labelHelper: "true"},
 			Annotations: map[string]string{
 				annStorageProvisioner:     storageClass.Provisioner,
 				annBetaStorageProvisioner: storageClass.Provisioner,
@@ -263,13 +306,15 @@ func (p *HybridProvisioner) createPVbyAnnotation(ctx context.Context, opts contr
 	var pvc *corev1.PersistentVolumeClaim
 
 	// Wait for the PV to be bound to the PVC
-	pvc, err = p.waitBindPVC(ctx, pvcreq)
+	pvc, err = p.waitBindPVC(ctx, pvcreq, pl.Timeouts.Bind)
 	if err != nil {
 		klog.ErrorS(err, "Error to bind persistent volume", "PVC", klog.KObj(pvcreq), "storageClass", klog.KObj(storageClass))
 		return nil, err
 	}
 
-	pv, err = p.releasePV(ctx, pvc)
+	p.recordPlacement(ctx, opts, pl, hybridv1alpha1.VolumePlacementReleasing, "")
+
+	pv, err = p.releasePV(ctx, pvc, pl.Timeouts.Backoff)
 	if err != nil {
 		klog.ErrorS(err, "Error to release persistent volume", "PVC", klog.KObj(pvc), "storageClass", klog.KObj(storageClass))
 		return nil, err
@@ -277,8 +322,12 @@ func (p *HybridProvisioner) createPVbyAnnotation(ctx context.Context, opts contr
 
 	klog.V(4).InfoS("Provision: persistent volume created", "PV", klog.KObj(pv), "storageClass", pv.Spec.StorageClassName)
 
+	if err = p.checkPVNodeAffinity(ctx, opts, pl, pvc, pv); err != nil {
+		return nil, err
+	}
+
 	// External provisioner can't update annotation on existence PV, so we need to patch PVC to bind it to the PV.
-	err = p.bondPVC(ctx, opts, pv.Name, storageClass)
+	err = p.bondPVC(ctx, opts, pl, pv)
 	if err != nil {
 		return nil, err
 	}
@@ -286,13 +335,16 @@ func (p *HybridProvisioner) createPVbyAnnotation(ctx context.Context, opts contr
 	return pv, nil
 }
 
-func (p *HybridProvisioner) createPVbyPOD(ctx context.Context, opts controller.ProvisionOptions, storageClass *storagev1.StorageClass) (pv *corev1.PersistentVolume, err error) {
+func (p *HybridProvisioner) createPVbyPOD(ctx context.Context, opts controller.ProvisionOptions, pl *placement) (pv *corev1.PersistentVolume, err error) {
+	storageClass := pl.StorageClass
+
 	klog.V(4).InfoS("createPVusingPOD: called", "pvc", klog.KObj(opts.PVC), "node", klog.KObj(opts.SelectedNode), "storageClass", klog.KObj(storageClass))
 
 	pvcreq := &corev1.PersistentVolumeClaim{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      opts.PVName,
 			Namespace: opts.PVC.Namespace,
+			Labels:    map[string]string{labelHelper: "true"},
 		},
 		Spec: corev1.PersistentVolumeClaimSpec{
 			AccessModes:      opts.PVC.Spec.AccessModes,
@@ -313,43 +365,8 @@ func (p *HybridProvisioner) createPVbyPOD(ctx context.Context, opts controller.P
 		}
 	}
 
-	pod := &corev1.Pod{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:      fmt.Sprintf("provisioner-%s", opts.PVName),
-			Namespace: pvcreq.Namespace,
-		},
-		Spec: corev1.PodSpec{
-			RestartPolicy: corev1.RestartPolicyNever,
-			Containers: []corev1.Container{
-				{
-					Name:  "provisioner",
-					Image: "registry.k8s.io/pause:3.10",
-					Resources: corev1.ResourceRequirements{
-						Requests: corev1.ResourceList{
-							corev1.ResourceCPU:    resource.MustParse("10m"),
-							corev1.ResourceMemory: resource.MustParse("10Mi"),
-						},
-						Limits: corev1.ResourceList{
-							corev1.ResourceCPU:    resource.MustParse("10m"),
-							corev1.ResourceMemory: resource.MustParse("10Mi"),
-						},
-					},
-				},
-			},
-			Tolerations: []corev1.Toleration{
-				{Operator: corev1.TolerationOpExists},
-			},
-			NodeSelector: map[string]string{
-				corev1.LabelHostname: opts.SelectedNode.Name,
-			},
-			Volumes: []corev1.Volume{
-				{
-					Name:         "provisioner",
-					VolumeSource: corev1.VolumeSource{PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: pvcreq.Name}},
-				},
-			},
-		},
-	}
+	pod := p.newHelperPod(fmt.Sprintf("provisioner-%s", opts.PVName), pvcreq, opts.SelectedNode.Name)
+	pod.Labels = map[string]string{labelHelper: "true"}
 
 	pod, err = p.client.CoreV1().Pods(pod.Namespace).Create(ctx, pod, metav1.CreateOptions{})
 	if err != nil && !errors.IsAlreadyExists(err) {
@@ -362,7 +379,7 @@ func (p *HybridProvisioner) createPVbyPOD(ctx context.Context, opts controller.P
 	)
 
 	// Wait for the pv to be bound to the pvc
-	pvc, err = p.waitBindPVC(ctx, pvcreq)
+	pvc, err = p.waitBindPVC(ctx, pvcreq, pl.Timeouts.Bind)
 	if err != nil {
 		klog.ErrorS(err, "Error to bind persistent volume", "pod", klog.KObj(pod), "PVC", klog.KObj(pvcreq), "storageClass", klog.KObj(storageClass))
 		return nil, err
@@ -370,7 +387,7 @@ func (p *HybridProvisioner) createPVbyPOD(ctx context.Context, opts controller.P
 
 	/// Now, we have pod + pvc + pv
 
-	err = wait.ExponentialBackoff(p.backoff, func() (bool, error) {
+	err = wait.ExponentialBackoff(pl.Timeouts.Backoff, func() (bool, error) {
 		klog.V(4).InfoS("Trying to delete pod", "pod", klog.KObj(pod))
 
 		if err = p.client.CoreV1().Pods(pod.Namespace).Delete(ctx, pod.Name, metav1.DeleteOptions{}); err == nil || errors.IsNotFound(err) {
@@ -387,7 +404,9 @@ func (p *HybridProvisioner) createPVbyPOD(ctx context.Context, opts controller.P
 		return nil, err
 	}
 
-	pv, err = p.releasePV(ctx, pvc)
+	p.recordPlacement(ctx, opts, pl, hybridv1alpha1.VolumePlacementReleasing, "")
+
+	pv, err = p.releasePV(ctx, pvc, pl.Timeouts.Backoff)
 	if err != nil {
 		klog.ErrorS(err, "Error to release persistent volume", "PVC", klog.KObj(pvc), "storageClass", klog.KObj(storageClass))
 		return nil, err
@@ -395,8 +414,12 @@ func (p *HybridProvisioner) createPVbyPOD(ctx context.Context, opts controller.P
 
 	klog.V(4).InfoS("Provision: persistent volume created", "PV", klog.KObj(pv), "storageClass", pv.Spec.StorageClassName)
 
+	if err = p.checkPVNodeAffinity(ctx, opts, pl, pvc, pv); err != nil {
+		return nil, err
+	}
+
 	// External provisioner can't update annotation on existence PV, so we need to patch PVC to bind it to the PV.
-	err = p.bondPVC(ctx, opts, pv.Name, storageClass)
+	err = p.bondPVC(ctx, opts, pl, pv)
 	if err != nil {
 		return nil, err
 	}
@@ -404,7 +427,47 @@ func (p *HybridProvisioner) createPVbyPOD(ctx context.Context, opts controller.P
 	return pv, nil
 }
 
-func (p *HybridProvisioner) bondPVC(ctx context.Context, opts controller.ProvisionOptions, pvName string, storageClass *storagev1.StorageClass) error {
+// newHelperPod returns the pod which consumes the claim on the node, to trigger the provisioning by the backend.
+func (p *HybridProvisioner) newHelperPod(name string, pvc *corev1.PersistentVolumeClaim, nodeName string) *corev1.Pod {
+	cfg := p.config.Get()
+
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: pvc.Namespace,
+		},
+		Spec: corev1.PodSpec{
+			RestartPolicy:     corev1.RestartPolicyNever,
+			PriorityClassName: cfg.HelperPod.PriorityClassName,
+			Containers: []corev1.Container{
+				{
+					Name:      "provisioner",
+					Image:     cfg.HelperPod.Image,
+					Resources: *cfg.HelperPod.Resources.DeepCopy(),
+				},
+			},
+			Tolerations: slices.Clone(cfg.HelperPod.Tolerations),
+			NodeSelector: map[string]string{
+				corev1.LabelHostname: nodeName,
+			},
+			Volumes: []corev1.Volume{
+				{
+					Name:         "provisioner",
+					VolumeSource: corev1.VolumeSource{PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: pvc.Name}},
+				},
+			},
+		},
+	}
+}
+
+func (p *HybridProvisioner) bondPVC(
+	ctx context.Context,
+	opts controller.ProvisionOptions,
+	pl *placement,
+	pv *corev1.PersistentVolume,
+) error {
+	storageClass := pl.StorageClass
+
 	patch, _ := json.Marshal(&corev1.PersistentVolumeClaim{ // nolint: errcheck,errchkjson
 		ObjectMeta: metav1.ObjectMeta{
 			Annotations: map[string]string{
@@ -415,7 +478,7 @@ func (p *HybridProvisioner) bondPVC(ctx context.Context, opts controller.Provisi
 			},
 		},
 		Spec: corev1.PersistentVolumeClaimSpec{
-			VolumeName: pvName,
+			VolumeName: pv.Name,
 		},
 	})
 
@@ -423,38 +486,50 @@ func (p *HybridProvisioner) bondPVC(ctx context.Context, opts controller.Provisi
 		return fmt.Errorf("failed to patch PersistentVolumeClaims: %v", err)
 	}
 
-	if storageClass.ReclaimPolicy != nil && *storageClass.ReclaimPolicy == corev1.PersistentVolumeReclaimDelete {
-		patch := fmt.Sprintf(
-			`{
-				"spec":
-				{
-					"persistentVolumeReclaimPolicy":"%s",
-					"claimRef":
-					{
-						"apiVersion":"%s",
-						"kind":"%s",
-						"name":"%s",
-						"namespace":"%s",
-						"uid":"%s"
-					}
-				}
-			}`,
-			corev1.PersistentVolumeReclaimDelete,
-			opts.PVC.APIVersion,
-			opts.PVC.Kind,
-			opts.PVC.Name,
-			opts.PVC.Namespace,
-			opts.PVC.UID,
-		)
-		if _, err := p.client.CoreV1().PersistentVolumes().Patch(ctx, pvName, types.MergePatchType, []byte(patch), metav1.PatchOptions{}); err != nil {
-			return fmt.Errorf("failed to patch PersistentVolume: %v", err)
-		}
+	// The PV is pre-bound to the user claim, with the reclaim policy and mount options of the hybrid StorageClass.
+	patch, _ = json.Marshal(&corev1.PersistentVolume{ // nolint: errcheck,errchkjson
+		ObjectMeta: metav1.ObjectMeta{
+			Labels:      pl.labels(),
+			Annotations: pl.annotations(),
+		},
+		Spec: corev1.PersistentVolumeSpec{
+			PersistentVolumeReclaimPolicy: getReclaimPolicy(opts.StorageClass),
+			MountOptions:                  mergeMountOptions(pl.Policy.MountOptionsPolicy, pl.Policy.MountOptions, pv.Spec.MountOptions),
+			ClaimRef: &corev1.ObjectReference{
+				APIVersion: "v1",
+				Kind:       "PersistentVolumeClaim",
+				Name:       opts.PVC.Name,
+				Namespace:  opts.PVC.Namespace,
+				UID:        opts.PVC.UID,
+			},
+		},
+	})
+
+	if _, err := p.client.CoreV1().PersistentVolumes().Patch(ctx, pv.Name, types.MergePatchType, patch, metav1.PatchOptions{}); err != nil {
+		return fmt.Errorf("failed to patch PersistentVolume: %v", err)
 	}
 
 	return nil
 }
 
-func (p *HybridProvisioner) waitBindPVC(ctx context.Context, pvc *corev1.PersistentVolumeClaim) (*corev1.PersistentVolumeClaim, error) {
+// getReclaimPolicy returns the reclaim policy of the StorageClass, Delete by default.
+func getReclaimPolicy(class *storagev1.StorageClass) corev1.PersistentVolumeReclaimPolicy {
+	if class.ReclaimPolicy == nil {
+		return corev1.PersistentVolumeReclaimDelete
+	}
+
+	// StorageClass validation allows only Delete and Retain.
+	switch policy := *class.ReclaimPolicy; policy {
+	case corev1.PersistentVolumeReclaimDelete, corev1.PersistentVolumeReclaimRetain:
+		return policy
+	default:
+		klog.InfoS("Unknown reclaim policy, using Delete", "storageClass", klog.KObj(class), "reclaimPolicy", policy)
+
+		return corev1.PersistentVolumeReclaimDelete
+	}
+}
+
+func (p *HybridProvisioner) waitBindPVC(ctx context.Context, pvc *corev1.PersistentVolumeClaim, bindTimeout time.Duration) (*corev1.PersistentVolumeClaim, error) {
 	watcher, err := p.client.CoreV1().PersistentVolumeClaims(pvc.Namespace).Watch(ctx, metav1.ListOptions{
 		FieldSelector: "metadata.name=" + pvc.Name,
 	})
@@ -464,7 +539,7 @@ func (p *HybridProvisioner) waitBindPVC(ctx context.Context, pvc *corev1.Persist
 
 	defer watcher.Stop()
 
-	timeout := time.After(time.Second * 30)
+	timeout := time.After(bindTimeout)
 
 	for {
 		select {
@@ -486,7 +561,7 @@ func (p *HybridProvisioner) waitBindPVC(ctx context.Context, pvc *corev1.Persist
 	}
 }
 
-func (p *HybridProvisioner) releasePV(ctx context.Context, pvc *corev1.PersistentVolumeClaim) (pv *corev1.PersistentVolume, err error) {
+func (p *HybridProvisioner) releasePV(ctx context.Context, pvc *corev1.PersistentVolumeClaim, backoff wait.Backoff) (pv *corev1.PersistentVolume, err error) {
 	var (
 		lastSaveError error
 		newFinalizers []string
@@ -515,7 +590,7 @@ func (p *HybridProvisioner) releasePV(ctx context.Context, pvc *corev1.Persisten
 		return nil, fmt.Errorf("failed to remove finalizer from persistentvolumeClaim: %v", err)
 	}
 
-	err = wait.ExponentialBackoff(p.backoff, func() (bool, error) {
+	err = wait.ExponentialBackoff(backoff, func() (bool, error) {
 		klog.V(4).InfoS("Trying to delete persistent volume claim", "PVC", klog.KObj(pvc))
 
 		policy := metav1.DeletePropagationForeground
@@ -0,0 +1,129 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provisioner
+
+import (
+	"context"
+	"testing"
+
+	controller "sigs.k8s.io/sig-storage-lib-external-provisioner/v10/controller"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestGetReclaimPolicy(t *testing.T) {
+	retain := corev1.PersistentVolumeReclaimRetain
+	del := corev1.PersistentVolumeReclaimDelete
+
+	tests := []struct {
+		name   string
+		policy *corev1.PersistentVolumeReclaimPolicy
+		want   corev1.PersistentVolumeReclaimPolicy
+	}{
+		{name: "nil", policy: nil, want: corev1.PersistentVolumeReclaimDelete},
+		{name: "delete", policy: &del, want: corev1.PersistentVolumeReclaimDelete},
+		{name: "retain", policy: &retain, want: corev1.PersistentVolumeReclaimRetain},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			class := newTestStorageClass("hybrid", DriverName, nil)
+			class.ReclaimPolicy = tt.policy
+
+			if got := getReclaimPolicy(class); got != tt.want {
+				t.Errorf("getReclaimPolicy() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBondPVC(t *testing.T) {
+	retain := corev1.PersistentVolumeReclaimRetain
+	del := corev1.PersistentVolumeReclaimDelete
+
+	tests := []struct {
+		name   string
+		policy *corev1.PersistentVolumeReclaimPolicy
+		want   corev1.PersistentVolumeReclaimPolicy
+	}{
+		{name: "nil", policy: nil, want: corev1.PersistentVolumeReclaimDelete},
+		{name: "delete", policy: &del, want: corev1.PersistentVolumeReclaimDelete},
+		{name: "retain", policy: &retain, want: corev1.PersistentVolumeReclaimRetain},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claim := newTestClaim("data", "1Gi", corev1.ReadWriteOnce)
+
+			// The released backend volume has the Retain policy and no claim.
+			pv := &corev1.PersistentVolume{
+				ObjectMeta: metav1.ObjectMeta{Name: "pvc-backend"},
+				Spec: corev1.PersistentVolumeSpec{
+					PersistentVolumeReclaimPolicy: corev1.PersistentVolumeReclaimRetain,
+					StorageClassName:              "backend",
+				},
+			}
+
+			p, client := newTestProvisioner(t, claim, pv)
+
+			hybrid := newTestStorageClass("hybrid", DriverName, nil)
+			hybrid.ReclaimPolicy = tt.policy
+
+			backend := newTestStorageClass("backend", "csi.example.com", nil)
+
+			opts := controller.ProvisionOptions{StorageClass: hybrid, PVC: claim}
+			pl := &placement{
+				Policy:       &hybridPolicy{Name: hybrid.Name, MountOptionsPolicy: mountOptionsPolicyUnion},
+				StorageClass: backend,
+				Method:       methodAnnotation,
+				Candidates:   []candidate{{Name: backend.Name}},
+			}
+
+			if err := p.bondPVC(context.Background(), opts, pl, pv); err != nil {
+				t.Fatalf("bondPVC() error = %v", err)
+			}
+
+			got, err := client.CoreV1().PersistentVolumes().Get(context.Background(), pv.Name, metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("failed to get PersistentVolume: %v", err)
+			}
+
+			if got.Spec.PersistentVolumeReclaimPolicy != tt.want {
+				t.Errorf("reclaim policy = %s, want %s", got.Spec.PersistentVolumeReclaimPolicy, tt.want)
+			}
+
+			ref := got.Spec.ClaimRef
+			if ref == nil || ref.Name != claim.Name || ref.Namespace != claim.Namespace || ref.UID != claim.UID {
+				t.Errorf("claimRef = %+v, want pre-bound to %s/%s", ref, claim.Namespace, claim.Name)
+			}
+
+			if got.Labels[LabelBackendStorageClass] != backend.Name {
+				t.Errorf("backend label = %q, want %q", got.Labels[LabelBackendStorageClass], backend.Name)
+			}
+
+			bound, err := client.CoreV1().PersistentVolumeClaims(claim.Namespace).Get(context.Background(), claim.Name, metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("failed to get PersistentVolumeClaim: %v", err)
+			}
+
+			if bound.Spec.VolumeName != pv.Name {
+				t.Errorf("claim volume name = %q, want %q", bound.Spec.VolumeName, pv.Name)
+			}
+		})
+	}
+}
@@ -0,0 +1,211 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provisioner
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+)
+
+// annRequeuedAt is the claim annotation, updated to trigger the re-evaluation of the pending claim by the provision controller.
+const annRequeuedAt = DriverName + "/requeued-at"
+
+// rejection is the node which rejected the claim, and the backend StorageClasses of the claim.
+// The provision controller removes the selected node of a rescheduled claim, so it is kept in memory.
+type rejection struct {
+	Claim          types.NamespacedName
+	Node           string
+	StorageClasses []string
+	Reason         string
+}
+
+// AddRequeueHandlers updates the pending hybrid claims when the node which rejected them gains a backend CSI driver,
+// or when one of their backend StorageClasses is created. The update of the claim makes the scheduler retry the pod,
+// without waiting for its backoff. The handlers are added by the leader replica only.
+func (p *HybridProvisioner) AddRequeueHandlers(ctx context.Context, csiNodeInformer, scInformer cache.SharedIndexInformer) error {
+	if _, err := csiNodeInformer.AddEventHandler(cache.ResourceEventHandlerDetailedFuncs{
+		AddFunc: func(obj any, isInInitialList bool) {
+			if csiNode, ok := obj.(*storagev1.CSINode); ok && !isInInitialList {
+				p.onCSINodeChanged(ctx, nil, csiNode)
+			}
+		},
+		UpdateFunc: func(oldObj, newObj any) {
+			oldCSINode, _ := oldObj.(*storagev1.CSINode) // nolint: errcheck
+			if csiNode, ok := newObj.(*storagev1.CSINode); ok && oldCSINode != nil {
+				p.onCSINodeChanged(ctx, oldCSINode, csiNode)
+			}
+		},
+	}); err != nil {
+		return fmt.Errorf("failed to add CSINode event handler: %v", err)
+	}
+
+	if _, err := scInformer.AddEventHandler(cache.ResourceEventHandlerDetailedFuncs{
+		AddFunc: func(obj any, isInInitialList bool) {
+			if class, ok := obj.(*storagev1.StorageClass); ok && !isInInitialList {
+				p.onStorageClassCreated(ctx, class)
+			}
+		},
+	}); err != nil {
+		return fmt.Errorf("failed to add StorageClass event handler: %v", err)
+	}
+
+	return nil
+}
+
+func (p *HybridProvisioner) onCSINodeChanged(ctx context.Context, oldCSINode, csiNode *storagev1.CSINode) {
+	gained := sets.New[string]()
+	for _, d := range csiNode.Spec.Drivers {
+		gained.Insert(d.Name)
+	}
+
+	if oldCSINode != nil {
+		for _, d := range oldCSINode.Spec.Drivers {
+			gained.Delete(d.Name)
+		}
+	}
+
+	if gained.Len() == 0 {
+		return
+	}
+
+	reason := fmt.Sprintf("node %s gained drivers %v", csiNode.Name, sets.List(gained))
+
+	var claims []types.NamespacedName
+
+	p.mu.Lock()
+
+	for uid, r := range p.rejections {
+		if r.Node != csiNode.Name {
+			continue
+		}
+
+		if slices.ContainsFunc(r.StorageClasses, func(name string) bool {
+			class, err := p.scLister.Get(name)
+
+			return err == nil && gained.Has(class.Provisioner)
+		}) {
+			klog.V(4).InfoS("Node of the rejected persistent volume claim gained a backend driver",
+				"PVC", klog.KRef(r.Claim.Namespace, r.Claim.Name), "node", csiNode.Name, "rejection", r.Reason)
+
+			claims = append(claims, r.Claim)
+			delete(p.rejections, uid)
+		}
+	}
+
+	p.mu.Unlock()
+
+	for _, claim := range claims {
+		if pvc, err := p.claimLister.PersistentVolumeClaims(claim.Namespace).Get(claim.Name); err != nil || pvc.Spec.VolumeName != "" {
+			continue
+		}
+
+		p.requeueClaim(ctx, claim, reason)
+	}
+}
+
+// recordRejection remembers the node which rejected the claim.
+func (p *HybridProvisioner) recordRejection(claim *corev1.PersistentVolumeClaim, node string, policy *hybridPolicy, reason string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.rejections[claim.UID] = &rejection{
+		Claim:          types.NamespacedName{Namespace: claim.Namespace, Name: claim.Name},
+		Node:           node,
+		StorageClasses: policy.StorageClasses,
+		Reason:         reason,
+	}
+}
+
+// forgetRejection removes the rejection of the claim, which is provisioned again or deleted.
+func (p *HybridProvisioner) forgetRejection(claim types.UID) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	delete(p.rejections, claim)
+}
+
+func (p *HybridProvisioner) onStorageClassCreated(ctx context.Context, class *storagev1.StorageClass) {
+	p.requeueClaims(ctx, fmt.Sprintf("storage class %s created", class.Name),
+		func(_ *corev1.PersistentVolumeClaim, policy *hybridPolicy) bool {
+			return slices.Contains(policy.StorageClasses, class.Name)
+		})
+}
+
+// requeueClaims updates the pending hybrid claims matching the function.
+func (p *HybridProvisioner) requeueClaims(ctx context.Context, reason string, match func(*corev1.PersistentVolumeClaim, *hybridPolicy) bool) {
+	claims, err := p.claimLister.List(labels.Everything())
+	if err != nil {
+		klog.ErrorS(err, "Failed to list persistent volume claims")
+
+		return
+	}
+
+	policies := map[string]*hybridPolicy{}
+
+	for _, claim := range claims {
+		if claim.Status.Phase != corev1.ClaimPending || claim.Spec.VolumeName != "" || claim.Spec.StorageClassName == nil {
+			continue
+		}
+
+		name := *claim.Spec.StorageClassName
+
+		policy, ok := policies[name]
+		if !ok {
+			if class, err := p.scLister.Get(name); err == nil && class.Provisioner == DriverName {
+				policy, _ = p.getHybridPolicy(class) // nolint: errcheck
+			}
+
+			policies[name] = policy
+		}
+
+		if policy == nil || !match(claim, policy) {
+			continue
+		}
+
+		p.requeueClaim(ctx, types.NamespacedName{Namespace: claim.Namespace, Name: claim.Name}, reason)
+	}
+}
+
+// requeueClaim updates the annotation of the claim. The update event makes the provision controller process the claim
+// with a selected node again, and makes the scheduler retry the pod of a rescheduled claim.
+func (p *HybridProvisioner) requeueClaim(ctx context.Context, claim types.NamespacedName, reason string) {
+	klog.V(4).InfoS("Requeue pending persistent volume claim", "PVC", klog.KRef(claim.Namespace, claim.Name), "reason", reason)
+
+	patch, _ := json.Marshal(&corev1.PersistentVolumeClaim{ // nolint: errcheck,errchkjson
+		ObjectMeta: metav1.ObjectMeta{
+			Annotations: map[string]string{
+				annRequeuedAt: time.Now().UTC().Format(time.RFC3339Nano),
+			},
+		},
+	})
+
+	if _, err := p.client.CoreV1().PersistentVolumeClaims(claim.Namespace).Patch(ctx, claim.Name, types.MergePatchType, patch, metav1.PatchOptions{}); err != nil {
+		klog.ErrorS(err, "Failed to requeue persistent volume claim", "PVC", klog.KRef(claim.Namespace, claim.Name))
+	}
+}
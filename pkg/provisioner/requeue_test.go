@@ -0,0 +1,78 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provisioner
+
+import (
+	"context"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestOnCSINodeChanged(t *testing.T) {
+	tests := []struct {
+		name     string
+		node     string
+		driver   string
+		requeued bool
+	}{
+		{name: "rejecting node gains the backend driver", node: "node-1", driver: "backend.csi", requeued: true},
+		{name: "rejecting node gains another driver", node: "node-1", driver: "other.csi", requeued: false},
+		{name: "another node gains the backend driver", node: "node-2", driver: "backend.csi", requeued: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claim := newTestClaim("data", "1Gi")
+			class := newTestStorageClass("backend", "backend.csi", nil)
+
+			p, client := newTestProvisioner(t, claim, class)
+			ctx := context.Background()
+
+			// The provision controller removes the selected node of the rescheduled claim.
+			p.recordRejection(claim, "node-1", &hybridPolicy{StorageClasses: []string{class.Name}}, "no matching storage class found")
+
+			p.onCSINodeChanged(ctx, newTestCSINode(tt.node), newTestCSINode(tt.node, tt.driver))
+
+			got, err := client.CoreV1().PersistentVolumeClaims(claim.Namespace).Get(ctx, claim.Name, metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("failed to get claim: %v", err)
+			}
+
+			if _, ok := got.Annotations[annRequeuedAt]; ok != tt.requeued {
+				t.Errorf("claim requeued %v, want %v", ok, tt.requeued)
+			}
+
+			if _, ok := p.rejections[claim.UID]; ok == tt.requeued {
+				t.Errorf("rejection kept %v, want %v", ok, !tt.requeued)
+			}
+		})
+	}
+}
+
+func TestForgetRejection(t *testing.T) {
+	claim := newTestClaim("data", "1Gi")
+
+	p, _ := newTestProvisioner(t)
+
+	p.recordRejection(claim, "node-1", &hybridPolicy{StorageClasses: []string{"backend"}}, "no matching storage class found")
+	p.onClaimDeleted(claim)
+
+	if _, ok := p.rejections[claim.UID]; ok {
+		t.Errorf("rejection of the deleted claim is kept")
+	}
+}
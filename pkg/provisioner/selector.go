@@ -0,0 +1,275 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provisioner
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+)
+
+// vaNodeIndex is the index of the VolumeAttachments by node name.
+const vaNodeIndex = "nodeName"
+
+// candidate is a backend StorageClass which was evaluated for the selected node.
+type candidate struct {
+	Name   string
+	Reason string
+}
+
+func formatCandidates(candidates []candidate) string {
+	res := make([]string, 0, len(candidates))
+
+	for _, c := range candidates {
+		if c.Reason == "" {
+			res = append(res, c.Name)
+		} else {
+			res = append(res, fmt.Sprintf("%s: %s", c.Name, c.Reason))
+		}
+	}
+
+	return strings.Join(res, "; ")
+}
+
+// Get first matched StorageClass from the list of storage classes supported by the selected node
+// The rejected storage classes are returned with the reason.
+func (p *HybridProvisioner) getStorageClassFromNode(
+	selectedNode *corev1.Node,
+	claim *corev1.PersistentVolumeClaim,
+	policy *hybridPolicy,
+) (*storagev1.StorageClass, []candidate, error) {
+	classes, rejected, err := p.getStorageClassesFromNode(selectedNode, claim, policy)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(classes) == 0 {
+		if required, _ := getClaimFeatures(claim); len(required) > 0 {
+			p.recorder.Eventf(claim, corev1.EventTypeWarning, "UnmetRequirements",
+				"No storage class provides required features %s on node %s: %s", strings.Join(required, ","), selectedNode.Name, formatCandidates(rejected))
+		}
+
+		return nil, rejected, fmt.Errorf("no matching storage class found for selected node %q: %s", selectedNode.Name, formatCandidates(rejected))
+	}
+
+	return classes[0], rejected, nil
+}
+
+// getStorageClassesFromNode returns the storage classes which can serve the claim on the selected node, in order of priority,
+// and the list of rejected storage classes with the reason.
+func (p *HybridProvisioner) getStorageClassesFromNode(
+	selectedNode *corev1.Node,
+	claim *corev1.PersistentVolumeClaim,
+	policy *hybridPolicy,
+) (classes []*storagev1.StorageClass, rejected []candidate, err error) {
+	// The node may have no CSINode object, if it has no CSI drivers. Non-CSI backends can still be used.
+	selectedCSINode, err := p.csiNodeLister.Get(selectedNode.Name)
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			return nil, nil, fmt.Errorf("error getting CSINode for selected node %q: %v", selectedNode.Name, err)
+		}
+
+		klog.V(4).InfoS("CSINode for selected node not found", "node", klog.KObj(selectedNode))
+	}
+
+	for _, storageClass := range policy.StorageClasses {
+		class, err := p.scLister.Get(storageClass)
+		if err != nil {
+			klog.V(4).InfoS("storage class is not found", "node", klog.KObj(selectedNode), "storageClass", storageClass)
+
+			rejected = append(rejected, candidate{Name: storageClass, Reason: "storage class not found"})
+
+			continue
+		}
+
+		err = p.checkBackendAvailable(class)
+		if err == nil {
+			err = p.checkStorageClass(selectedNode, selectedCSINode, claim, class, policy)
+		}
+
+		if err != nil {
+			klog.V(4).InfoS("storage class is not suitable", "node", klog.KObj(selectedNode), "storageClass", storageClass, "reason", err.Error())
+
+			rejected = append(rejected, candidate{Name: storageClass, Reason: err.Error()})
+
+			continue
+		}
+
+		classes = append(classes, class)
+	}
+
+	classes, sticky := p.stickyStorageClasses(classes, claim, policy)
+	rejected = append(rejected, sticky...)
+
+	p.spreadStorageClasses(classes, claim, policy)
+
+	_, preferred := getClaimFeatures(claim)
+	sortByPreferredFeatures(classes, preferred, policy)
+
+	return classes, rejected, nil
+}
+
+// checkStorageClass returns an error if the storage class cannot serve the claim on the selected node.
+func (p *HybridProvisioner) checkStorageClass(
+	selectedNode *corev1.Node,
+	selectedCSINode *storagev1.CSINode,
+	claim *corev1.PersistentVolumeClaim,
+	class *storagev1.StorageClass,
+	policy *hybridPolicy,
+) error {
+	_, err := p.driverLister.Get(class.Provisioner)
+	isCSIDriver := err == nil
+
+	if len(class.AllowedTopologies) > 0 {
+		if isCSIDriver {
+			if err := checkCSITopology(selectedNode, selectedCSINode, class); err != nil {
+				return err
+			}
+		} else if !matchTopologySelectorTerms(selectedNode.Labels, class.AllowedTopologies) {
+			return fmt.Errorf("node labels do not match allowed topologies")
+		}
+	}
+
+	caps, err := policy.getCapabilities(class)
+	if err != nil {
+		return err
+	}
+
+	if caps.NodeSelector != nil && !caps.NodeSelector.Matches(labels.Set(selectedNode.Labels)) {
+		return fmt.Errorf("node does not match the backend node selector")
+	}
+
+	required, _ := getClaimFeatures(claim)
+	if missing := missingFeatures(caps.Features, required); len(missing) > 0 {
+		return fmt.Errorf("missing required features %s", strings.Join(missing, ","))
+	}
+
+	if err := caps.check(claim); err != nil {
+		return err
+	}
+
+	if !isCSIDriver {
+		// Provisioner is not a CSI driver
+		return nil
+	}
+
+	if selectedCSINode == nil {
+		return fmt.Errorf("CSINode for selected node %q not found", selectedNode.Name)
+	}
+
+	for _, driver := range selectedCSINode.Spec.Drivers {
+		if driver.Name == class.Provisioner {
+			if driver.Allocatable != nil && driver.Allocatable.Count != nil {
+				attached, err := p.getAttachedVolumes(selectedNode.Name, driver.Name)
+				if err != nil {
+					return err
+				}
+
+				if attached >= int(*driver.Allocatable.Count) {
+					return fmt.Errorf("driver %s reached the attach limit %d", driver.Name, *driver.Allocatable.Count)
+				}
+			}
+
+			return nil
+		}
+	}
+
+	return fmt.Errorf("driver %s is not registered on the node", class.Provisioner)
+}
+
+// checkCSITopology returns an error if the node topology, reported by the CSI driver, is not allowed by the storage class.
+func checkCSITopology(selectedNode *corev1.Node, selectedCSINode *storagev1.CSINode, class *storagev1.StorageClass) error {
+	if selectedCSINode == nil {
+		return fmt.Errorf("CSINode for selected node %q not found", selectedNode.Name)
+	}
+
+	topologyKeys := getTopologyKeys(selectedCSINode, class.Provisioner)
+
+	selectedTopology, isMissingKey := getTopologyFromNode(selectedNode, topologyKeys)
+	if isMissingKey {
+		return fmt.Errorf("node topology key is missing")
+	}
+
+	for _, t := range flatten(class.AllowedTopologies) {
+		if t.subset(selectedTopology) {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("topology %v is not allowed", selectedTopology)
+}
+
+// matchTopologySelectorTerms returns true if the node labels match any of the topology selector terms.
+func matchTopologySelectorTerms(nodeLabels map[string]string, terms []corev1.TopologySelectorTerm) bool {
+	for _, term := range terms {
+		matched := true
+
+		for _, expr := range term.MatchLabelExpressions {
+			v, ok := nodeLabels[expr.Key]
+			if !ok || !slices.Contains(expr.Values, v) {
+				matched = false
+
+				break
+			}
+		}
+
+		if matched {
+			return true
+		}
+	}
+
+	return false
+}
+
+// getAttachedVolumes returns the number of volumes attached to the node by the driver.
+func (p *HybridProvisioner) getAttachedVolumes(nodeName, driverName string) (int, error) {
+	attachments, err := p.vaIndexer.ByIndex(vaNodeIndex, nodeName)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list volumeattachments: %v", err)
+	}
+
+	count := 0
+
+	for _, obj := range attachments {
+		if va, ok := obj.(*storagev1.VolumeAttachment); ok && va.Spec.Attacher == driverName {
+			count++
+		}
+	}
+
+	return count, nil
+}
+
+// AddVolumeAttachmentIndexers indexes the VolumeAttachments by node name, it must be called before the informer is started.
+func AddVolumeAttachmentIndexers(informer cache.SharedIndexInformer) error {
+	return informer.AddIndexers(cache.Indexers{
+		vaNodeIndex: func(obj any) ([]string, error) {
+			va, ok := obj.(*storagev1.VolumeAttachment)
+			if !ok {
+				return nil, nil
+			}
+
+			return []string{va.Spec.NodeName}, nil
+		},
+	})
+}
@@ -0,0 +1,154 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provisioner
+
+import (
+	"slices"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestGetStorageClassesFromNode(t *testing.T) {
+	p, _ := newTestProvisioner(t,
+		newTestNode("node-1", nil),
+		newTestCSINode("node-1", "csi.fast.com", "csi.large.com"),
+		newTestCSIDriver("csi.fast.com"),
+		newTestCSIDriver("csi.large.com"),
+		newTestCSIDriver("csi.remote.com"),
+		newTestStorageClass("fast", "csi.fast.com", map[string]string{
+			annAccessModes: "ReadWriteOnce",
+			annMaxSize:     "10Gi",
+		}),
+		newTestStorageClass("large", "csi.large.com", map[string]string{
+			annAccessModes: "ReadWriteOnce,ReadWriteMany",
+			annMinSize:     "1Gi",
+		}),
+		newTestStorageClass("remote", "csi.remote.com", nil),
+	)
+
+	node := newTestNode("node-1", nil)
+	policy := &hybridPolicy{
+		Name:           "hybrid",
+		StorageClasses: []string{"fast", "large", "remote", "missing"},
+		SpreadPolicy:   spreadPolicyNone,
+	}
+
+	tests := []struct {
+		name     string
+		claim    *corev1.PersistentVolumeClaim
+		want     []string
+		rejected []string
+	}{
+		{
+			name:     "all capable backends in order",
+			claim:    newTestClaim("pvc", "5Gi", corev1.ReadWriteOnce),
+			want:     []string{"fast", "large"},
+			rejected: []string{"remote", "missing"},
+		},
+		{
+			name:     "access mode",
+			claim:    newTestClaim("pvc", "5Gi", corev1.ReadWriteMany),
+			want:     []string{"large"},
+			rejected: []string{"fast", "remote", "missing"},
+		},
+		{
+			name:     "maximum size",
+			claim:    newTestClaim("pvc", "20Gi", corev1.ReadWriteOnce),
+			want:     []string{"large"},
+			rejected: []string{"fast", "remote", "missing"},
+		},
+		{
+			name:     "minimum size",
+			claim:    newTestClaim("pvc", "512Mi", corev1.ReadWriteOnce),
+			want:     []string{"fast"},
+			rejected: []string{"large", "remote", "missing"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			classes, rejected, err := p.getStorageClassesFromNode(node, tt.claim, policy)
+			if err != nil {
+				t.Fatalf("getStorageClassesFromNode() error = %v", err)
+			}
+
+			if got := storageClassNames(classes); !slices.Equal(got, tt.want) {
+				t.Errorf("classes = %v, want %v", got, tt.want)
+			}
+
+			var got []string
+			for _, c := range rejected {
+				if c.Reason == "" {
+					t.Errorf("rejected storage class %s has no reason", c.Name)
+				}
+
+				got = append(got, c.Name)
+			}
+
+			if !slices.Equal(got, tt.rejected) {
+				t.Errorf("rejected = %v, want %v", got, tt.rejected)
+			}
+		})
+	}
+}
+
+func TestGetStorageClassesFromNodeAttachLimit(t *testing.T) {
+	newAttachment := func(name, node, attacher string) *storagev1.VolumeAttachment {
+		return &storagev1.VolumeAttachment{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec:       storagev1.VolumeAttachmentSpec{NodeName: node, Attacher: attacher},
+		}
+	}
+
+	limit := int32(2)
+
+	csiNode := newTestCSINode("node-1", "csi.fast.com", "csi.large.com")
+	for i := range csiNode.Spec.Drivers {
+		csiNode.Spec.Drivers[i].Allocatable = &storagev1.VolumeNodeResources{Count: &limit}
+	}
+
+	p, _ := newTestProvisioner(t,
+		csiNode,
+		newTestCSIDriver("csi.fast.com"),
+		newTestCSIDriver("csi.large.com"),
+		newTestStorageClass("fast", "csi.fast.com", nil),
+		newTestStorageClass("large", "csi.large.com", nil),
+		newAttachment("va-1", "node-1", "csi.fast.com"),
+		newAttachment("va-2", "node-1", "csi.fast.com"),
+		newAttachment("va-3", "node-1", "csi.large.com"),
+		newAttachment("va-4", "node-2", "csi.large.com"),
+		newAttachment("va-5", "node-2", "csi.large.com"),
+	)
+
+	policy := &hybridPolicy{Name: "hybrid", StorageClasses: []string{"fast", "large"}}
+
+	classes, rejected, err := p.getStorageClassesFromNode(newTestNode("node-1", nil), newTestClaim("pvc", "1Gi", corev1.ReadWriteOnce), policy)
+	if err != nil {
+		t.Fatalf("getStorageClassesFromNode() error = %v", err)
+	}
+
+	if got := storageClassNames(classes); !slices.Equal(got, []string{"large"}) {
+		t.Errorf("classes = %v, want [large]", got)
+	}
+
+	if len(rejected) != 1 || rejected[0].Name != "fast" {
+		t.Errorf("rejected = %v, want fast", rejected)
+	}
+}
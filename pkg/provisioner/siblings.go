@@ -0,0 +1,88 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provisioner
+
+import (
+	"regexp"
+	"slices"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/klog/v2"
+)
+
+// statefulSetClaimName matches the name of the claims created from a StatefulSet volumeClaimTemplate,
+// <template>-<statefulset>-<ordinal>
+var statefulSetClaimName = regexp.MustCompile(`^(.+)-[0-9]+$`)
+
+// getClaimGroup returns the group of the claim, the claims with the same group belong to the same workload.
+// Empty group means the claim does not belong to any workload.
+func getClaimGroup(claim *corev1.PersistentVolumeClaim) string {
+	if owner := metav1.GetControllerOf(claim); owner != nil {
+		return string(owner.UID)
+	}
+
+	if m := statefulSetClaimName.FindStringSubmatch(claim.Name); m != nil {
+		return m[1]
+	}
+
+	return ""
+}
+
+// getSiblingVolumes returns the bound volumes of the claims from the same group and hybrid StorageClass,
+// ordered by the claim creation time.
+func (p *HybridProvisioner) getSiblingVolumes(claim *corev1.PersistentVolumeClaim) []*corev1.PersistentVolume {
+	group := getClaimGroup(claim)
+	if group == "" {
+		return nil
+	}
+
+	claims, err := p.claimLister.PersistentVolumeClaims(claim.Namespace).List(labels.Everything())
+	if err != nil {
+		klog.ErrorS(err, "Failed to list persistent volume claims", "namespace", claim.Namespace)
+
+		return nil
+	}
+
+	slices.SortStableFunc(claims, func(a, b *corev1.PersistentVolumeClaim) int {
+		return a.CreationTimestamp.Compare(b.CreationTimestamp.Time)
+	})
+
+	var volumes []*corev1.PersistentVolume
+
+	for _, c := range claims {
+		if c.UID == claim.UID || c.Spec.VolumeName == "" || getClaimGroup(c) != group {
+			continue
+		}
+
+		if c.Spec.StorageClassName == nil || claim.Spec.StorageClassName == nil || *c.Spec.StorageClassName != *claim.Spec.StorageClassName {
+			continue
+		}
+
+		pv, err := p.pvLister.Get(c.Spec.VolumeName)
+		if err != nil {
+			klog.V(4).InfoS("Failed to get persistent volume", "PVC", klog.KObj(c), "PV", c.Spec.VolumeName, "err", err)
+
+			continue
+		}
+
+		volumes = append(volumes, pv)
+	}
+
+	return volumes
+}
@@ -0,0 +1,61 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provisioner
+
+import (
+	"slices"
+
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+)
+
+// spreadStorageClasses sorts the storage classes according to the spread policy.
+func (p *HybridProvisioner) spreadStorageClasses(classes []*storagev1.StorageClass, claim *corev1.PersistentVolumeClaim, policy *hybridPolicy) {
+	if len(classes) < 2 {
+		return
+	}
+
+	switch policy.SpreadPolicy {
+	case spreadPolicySpread:
+		if claim == nil {
+			return
+		}
+
+		backends := map[string]int{}
+		for _, pv := range p.getSiblingVolumes(claim) {
+			backends[pv.Spec.StorageClassName]++
+		}
+
+		if len(backends) == 0 {
+			return
+		}
+
+		slices.SortStableFunc(classes, func(a, b *storagev1.StorageClass) int {
+			return backends[a.Name] - backends[b.Name]
+		})
+	case spreadPolicyRoundRobin:
+		p.mu.Lock()
+		n := p.roundRobin[policy.Name]
+		p.roundRobin[policy.Name] = n + 1
+		p.mu.Unlock()
+
+		n %= len(classes)
+
+		rotated := append(slices.Clone(classes[n:]), classes[:n]...)
+		copy(classes, rotated)
+	}
+}
@@ -0,0 +1,206 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provisioner
+
+import (
+	"slices"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestSpreadStorageClasses(t *testing.T) {
+	p, _ := newTestProvisioner(t,
+		newTestBoundClaim("data-web-0", "hybrid", "pv-0", 100),
+		newTestBoundClaim("data-web-1", "hybrid", "pv-1", 200),
+		newTestBoundClaim("data-db-0", "hybrid", "pv-2", 300),
+		newTestPV("pv-0", "a"),
+		newTestPV("pv-1", "a"),
+		newTestPV("pv-2", "b"),
+	)
+
+	newClasses := func() []*storagev1.StorageClass {
+		return []*storagev1.StorageClass{
+			newTestStorageClass("a", "csi.a.com", nil),
+			newTestStorageClass("b", "csi.b.com", nil),
+			newTestStorageClass("c", "csi.c.com", nil),
+		}
+	}
+
+	hybrid := "hybrid"
+
+	claim := newTestClaim("data-web-2", "1Gi", corev1.ReadWriteOnce)
+	claim.Spec.StorageClassName = &hybrid
+
+	tests := []struct {
+		name   string
+		policy string
+		claim  *corev1.PersistentVolumeClaim
+		want   []string
+	}{
+		{
+			name:   "none keeps the order",
+			policy: spreadPolicyNone,
+			claim:  claim,
+			want:   []string{"a", "b", "c"},
+		},
+		{
+			name:   "spread prefers the least used backends of the workload",
+			policy: spreadPolicySpread,
+			claim:  claim,
+			want:   []string{"b", "c", "a"},
+		},
+		{
+			name:   "spread without workload keeps the order",
+			policy: spreadPolicySpread,
+			claim:  newTestClaim("standalone", "1Gi", corev1.ReadWriteOnce),
+			want:   []string{"a", "b", "c"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			classes := newClasses()
+
+			p.spreadStorageClasses(classes, tt.claim, &hybridPolicy{Name: "hybrid", SpreadPolicy: tt.policy})
+
+			if got := storageClassNames(classes); !slices.Equal(got, tt.want) {
+				t.Errorf("spreadStorageClasses() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSpreadStorageClassesRoundRobin(t *testing.T) {
+	p, _ := newTestProvisioner(t)
+
+	policy := &hybridPolicy{Name: "hybrid", SpreadPolicy: spreadPolicyRoundRobin}
+
+	var got []string
+
+	for range 4 {
+		classes := []*storagev1.StorageClass{
+			newTestStorageClass("a", "csi.a.com", nil),
+			newTestStorageClass("b", "csi.b.com", nil),
+			newTestStorageClass("c", "csi.c.com", nil),
+		}
+
+		p.spreadStorageClasses(classes, nil, policy)
+		got = append(got, classes[0].Name)
+	}
+
+	if want := []string{"a", "b", "c", "a"}; !slices.Equal(got, want) {
+		t.Errorf("round robin first backends = %v, want %v", got, want)
+	}
+}
+
+func TestParseHybridPolicy(t *testing.T) {
+	class := &storagev1.StorageClass{ObjectMeta: metav1.ObjectMeta{Name: "hybrid"}}
+
+	tests := []struct {
+		name    string
+		params  map[string]string
+		want    *hybridPolicy
+		wantErr bool
+	}{
+		{
+			name:    "storage classes are required",
+			params:  map[string]string{},
+			wantErr: true,
+		},
+		{
+			name:    "empty storage classes",
+			params:  map[string]string{paramStorageClasses: " , "},
+			wantErr: true,
+		},
+		{
+			name:   "defaults",
+			params: map[string]string{paramStorageClasses: "a, b"},
+			want: &hybridPolicy{
+				Name:                 "hybrid",
+				StorageClasses:       []string{"a", "b"},
+				SpreadPolicy:         spreadPolicyNone,
+				ConsistencyPolicy:    consistencyPolicyNone,
+				NodeAffinityMismatch: nodeAffinityMismatchFail,
+				MountOptionsPolicy:   mountOptionsPolicyUnion,
+			},
+		},
+		{
+			name: "all parameters",
+			params: map[string]string{
+				paramStorageClasses:       "a",
+				paramSpreadPolicy:         spreadPolicyRoundRobin,
+				paramNodeAffinityMismatch: nodeAffinityMismatchFailover,
+				paramMountOptionsPolicy:   mountOptionsPolicyBackend,
+			},
+			want: &hybridPolicy{
+				Name:                 "hybrid",
+				StorageClasses:       []string{"a"},
+				SpreadPolicy:         spreadPolicyRoundRobin,
+				ConsistencyPolicy:    consistencyPolicyNone,
+				NodeAffinityMismatch: nodeAffinityMismatchFailover,
+				MountOptionsPolicy:   mountOptionsPolicyBackend,
+			},
+		},
+		{
+			name:    "unknown spread policy",
+			params:  map[string]string{paramStorageClasses: "a", paramSpreadPolicy: "random"},
+			wantErr: true,
+		},
+		{
+			name:    "unknown consistency policy",
+			params:  map[string]string{paramStorageClasses: "a", paramConsistencyPolicy: "eventual"},
+			wantErr: true,
+		},
+		{
+			name:    "unknown node affinity mismatch",
+			params:  map[string]string{paramStorageClasses: "a", paramNodeAffinityMismatch: "ignore"},
+			wantErr: true,
+		},
+		{
+			name:    "unknown mount options policy",
+			params:  map[string]string{paramStorageClasses: "a", paramMountOptionsPolicy: "intersection"},
+			wantErr: true,
+		},
+		{
+			name:    "sticky with spread",
+			params:  map[string]string{paramStorageClasses: "a", paramConsistencyPolicy: consistencyPolicySticky, paramSpreadPolicy: spreadPolicySpread},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseHybridPolicy(class, tt.params)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseHybridPolicy() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if tt.want == nil {
+				return
+			}
+
+			if got.Name != tt.want.Name || !slices.Equal(got.StorageClasses, tt.want.StorageClasses) ||
+				got.SpreadPolicy != tt.want.SpreadPolicy || got.ConsistencyPolicy != tt.want.ConsistencyPolicy ||
+				got.NodeAffinityMismatch != tt.want.NodeAffinityMismatch || got.MountOptionsPolicy != tt.want.MountOptionsPolicy {
+				t.Errorf("parseHybridPolicy() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
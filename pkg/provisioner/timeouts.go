@@ -0,0 +1,95 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provisioner
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+const (
+	// Backend StorageClass annotations, overriding the timeouts and backoff of the configuration.
+	annBindTimeout     = DriverName + "/bind-timeout"
+	annDeleteTimeout   = DriverName + "/delete-timeout"
+	annBackoffDuration = DriverName + "/backoff-duration"
+	annBackoffFactor   = DriverName + "/backoff-factor"
+	annBackoffSteps    = DriverName + "/backoff-steps"
+)
+
+// backendTimeouts is the timeouts and backoff used with the backend StorageClass.
+type backendTimeouts struct {
+	// Bind is the time to wait for the backend to bind the volume.
+	Bind time.Duration
+	// Delete is the time to wait for the backend to delete the volume.
+	Delete time.Duration
+	// Backoff is the backoff of the retried API calls.
+	Backoff wait.Backoff
+}
+
+// getBackendTimeouts returns the timeouts of the configuration, overridden by the backend StorageClass annotations.
+func (p *HybridProvisioner) getBackendTimeouts(class *storagev1.StorageClass) (*backendTimeouts, error) {
+	cfg := p.config.Get()
+
+	t := &backendTimeouts{
+		Bind:    cfg.Timeouts.Bind.Duration,
+		Delete:  cfg.Timeouts.Delete.Duration,
+		Backoff: cfg.Backoff.WaitBackoff(),
+	}
+
+	for ann, d := range map[string]*time.Duration{
+		annBindTimeout:     &t.Bind,
+		annDeleteTimeout:   &t.Delete,
+		annBackoffDuration: &t.Backoff.Duration,
+	} {
+		if v := class.Annotations[ann]; v != "" {
+			duration, err := time.ParseDuration(v)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse annotation %s of storage class %s: %v", ann, class.Name, err)
+			}
+
+			if duration <= 0 {
+				return nil, fmt.Errorf("annotation %s of storage class %s must be positive", ann, class.Name)
+			}
+
+			*d = duration
+		}
+	}
+
+	if v := class.Annotations[annBackoffFactor]; v != "" {
+		factor, err := strconv.ParseFloat(v, 64)
+		if err != nil || factor < 1 {
+			return nil, fmt.Errorf("annotation %s of storage class %s must be a number not less than 1", annBackoffFactor, class.Name)
+		}
+
+		t.Backoff.Factor = factor
+	}
+
+	if v := class.Annotations[annBackoffSteps]; v != "" {
+		steps, err := strconv.Atoi(v)
+		if err != nil || steps < 1 {
+			return nil, fmt.Errorf("annotation %s of storage class %s must be a positive integer", annBackoffSteps, class.Name)
+		}
+
+		t.Backoff.Steps = steps
+	}
+
+	return t, nil
+}
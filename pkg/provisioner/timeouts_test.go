@@ -0,0 +1,101 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provisioner
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+func TestGetBackendTimeouts(t *testing.T) {
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		want        backendTimeouts
+		wantErr     string
+	}{
+		{
+			name: "configuration defaults",
+			want: backendTimeouts{
+				Bind:    30 * time.Second,
+				Delete:  10 * time.Minute,
+				Backoff: wait.Backoff{Duration: 10 * time.Second, Factor: 1, Steps: 5},
+			},
+		},
+		{
+			name: "overridden timeouts and backoff",
+			annotations: map[string]string{
+				annBindTimeout:     "2m",
+				annDeleteTimeout:   "1h",
+				annBackoffDuration: "5s",
+				annBackoffFactor:   "2",
+				annBackoffSteps:    "3",
+			},
+			want: backendTimeouts{
+				Bind:    2 * time.Minute,
+				Delete:  time.Hour,
+				Backoff: wait.Backoff{Duration: 5 * time.Second, Factor: 2, Steps: 3},
+			},
+		},
+		{
+			name:        "invalid duration",
+			annotations: map[string]string{annBindTimeout: "soon"},
+			wantErr:     "failed to parse annotation",
+		},
+		{
+			name:        "negative duration",
+			annotations: map[string]string{annDeleteTimeout: "-1m"},
+			wantErr:     "must be positive",
+		},
+		{
+			name:        "factor below 1",
+			annotations: map[string]string{annBackoffFactor: "0.5"},
+			wantErr:     "not less than 1",
+		},
+		{
+			name:        "zero steps",
+			annotations: map[string]string{annBackoffSteps: "0"},
+			wantErr:     "must be a positive integer",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, _ := newTestProvisioner(t)
+
+			got, err := p.getBackendTimeouts(newTestStorageClass("backend", "backend.csi", tt.annotations))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("expected error %q, got %v", tt.wantErr, err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if *got != tt.want {
+				t.Errorf("got %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
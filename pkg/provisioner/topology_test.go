@@ -0,0 +1,141 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provisioner
+
+import (
+	"slices"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestMatchTopologySelectorTerms(t *testing.T) {
+	terms := []corev1.TopologySelectorTerm{
+		{
+			MatchLabelExpressions: []corev1.TopologySelectorLabelRequirement{
+				{Key: corev1.LabelTopologyRegion, Values: []string{"region-1"}},
+				{Key: corev1.LabelTopologyZone, Values: []string{"zone-a", "zone-b"}},
+			},
+		},
+		{
+			MatchLabelExpressions: []corev1.TopologySelectorLabelRequirement{
+				{Key: "local-storage", Values: []string{"true"}},
+			},
+		},
+	}
+
+	tests := []struct {
+		name   string
+		labels map[string]string
+		want   bool
+	}{
+		{
+			name:   "all expressions of a term",
+			labels: map[string]string{corev1.LabelTopologyRegion: "region-1", corev1.LabelTopologyZone: "zone-b"},
+			want:   true,
+		},
+		{
+			name:   "value not allowed",
+			labels: map[string]string{corev1.LabelTopologyRegion: "region-1", corev1.LabelTopologyZone: "zone-c"},
+			want:   false,
+		},
+		{
+			name:   "missing label",
+			labels: map[string]string{corev1.LabelTopologyZone: "zone-a"},
+			want:   false,
+		},
+		{
+			name:   "second term",
+			labels: map[string]string{"local-storage": "true"},
+			want:   true,
+		},
+		{
+			name: "no labels",
+			want: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := matchTopologySelectorTerms(tt.labels, terms); got != tt.want {
+				t.Errorf("matchTopologySelectorTerms() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetStorageClassesFromNodeTopology(t *testing.T) {
+	csiNode := newTestCSINode("node-1", "csi.zonal.com")
+	csiNode.Spec.Drivers[0].TopologyKeys = []string{corev1.LabelTopologyZone}
+
+	zonal := newTestStorageClass("zonal", "csi.zonal.com", nil)
+	zonal.AllowedTopologies = zoneTerms("zone-a")
+
+	local := newTestStorageClass("local", "rancher.io/local-path", nil)
+	local.AllowedTopologies = zoneTerms("zone-b")
+
+	anywhere := newTestStorageClass("anywhere", "rancher.io/local-path", nil)
+
+	tests := []struct {
+		name    string
+		node    *corev1.Node
+		csiNode *storagev1.CSINode
+		want    []string
+	}{
+		{
+			name:    "zone a",
+			node:    newTestNode("node-1", map[string]string{corev1.LabelTopologyZone: "zone-a"}),
+			csiNode: csiNode,
+			want:    []string{"zonal", "anywhere"},
+		},
+		{
+			name:    "zone b",
+			node:    newTestNode("node-1", map[string]string{corev1.LabelTopologyZone: "zone-b"}),
+			csiNode: csiNode,
+			want:    []string{"local", "anywhere"},
+		},
+		{
+			name: "node without CSINode uses non-CSI backends",
+			node: newTestNode("node-1", map[string]string{corev1.LabelTopologyZone: "zone-b"}),
+			want: []string{"local", "anywhere"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			objects := []runtime.Object{newTestCSIDriver("csi.zonal.com"), zonal, local, anywhere}
+			if tt.csiNode != nil {
+				objects = append(objects, tt.csiNode)
+			}
+
+			p, _ := newTestProvisioner(t, objects...)
+
+			policy := &hybridPolicy{Name: "hybrid", StorageClasses: []string{"zonal", "local", "anywhere"}}
+
+			classes, _, err := p.getStorageClassesFromNode(tt.node, newTestClaim("pvc", "1Gi", corev1.ReadWriteOnce), policy)
+			if err != nil {
+				t.Fatalf("getStorageClassesFromNode() error = %v", err)
+			}
+
+			if got := storageClassNames(classes); !slices.Equal(got, tt.want) {
+				t.Errorf("classes = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,152 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provisioner
+
+import (
+	"context"
+	"fmt"
+
+	controller "sigs.k8s.io/sig-storage-lib-external-provisioner/v10/controller"
+
+	hybridv1alpha1 "github.com/sergelogvinov/hybrid-csi-plugin/pkg/apis/hybrid/v1alpha1"
+
+	errors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/util/retry"
+	"k8s.io/klog/v2"
+)
+
+// recordPlacement records the new phase of the provisioning in the VolumePlacement of the claim.
+// The intermediate phases are kept in the timings and written with the next phase, to save API calls.
+// Errors are logged only, the audit trail must not block the provisioning.
+func (p *HybridProvisioner) recordPlacement(
+	ctx context.Context,
+	opts controller.ProvisionOptions,
+	pl *placement,
+	phase hybridv1alpha1.VolumePlacementPhase,
+	message string,
+) {
+	pl.Timings = append(pl.Timings, hybridv1alpha1.VolumePlacementTiming{Phase: phase, Time: metav1.Now()})
+
+	if p.dynamicClient == nil {
+		return
+	}
+
+	switch phase {
+	case hybridv1alpha1.VolumePlacementBinding, hybridv1alpha1.VolumePlacementReleasing:
+		return
+	}
+
+	if err := p.updatePlacement(ctx, opts, pl, phase, message); err != nil {
+		klog.ErrorS(err, "Failed to record volume placement", "PVC", klog.KObj(opts.PVC), "phase", phase)
+	}
+}
+
+func (p *HybridProvisioner) updatePlacement(
+	ctx context.Context,
+	opts controller.ProvisionOptions,
+	pl *placement,
+	phase hybridv1alpha1.VolumePlacementPhase,
+	message string,
+) error {
+	vp := &hybridv1alpha1.VolumePlacement{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: hybridv1alpha1.SchemeGroupVersion.String(),
+			Kind:       "VolumePlacement",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      opts.PVC.Name,
+			Namespace: opts.PVC.Namespace,
+			OwnerReferences: []metav1.OwnerReference{
+				{
+					APIVersion: "v1",
+					Kind:       "PersistentVolumeClaim",
+					Name:       opts.PVC.Name,
+					UID:        opts.PVC.UID,
+				},
+			},
+		},
+		Spec: hybridv1alpha1.VolumePlacementSpec{
+			ClaimName:        opts.PVC.Name,
+			StorageClassName: pl.Policy.Name,
+		},
+		Status: hybridv1alpha1.VolumePlacementStatus{
+			Phase:        phase,
+			Message:      message,
+			SelectedNode: opts.SelectedNode.Name,
+			Method:       pl.Method,
+			VolumeName:   pl.VolumeName,
+			Timings:      pl.Timings,
+		},
+	}
+
+	if pl.StorageClass != nil {
+		vp.Status.BackendStorageClassName = pl.StorageClass.Name
+	}
+
+	for _, c := range pl.Candidates {
+		if c.Reason != "" {
+			vp.Status.RejectedCandidates = append(vp.Status.RejectedCandidates, hybridv1alpha1.VolumePlacementCandidate{Name: c.Name, Reason: c.Reason})
+		}
+	}
+
+	obj, err := runtime.DefaultUnstructuredConverter.ToUnstructured(vp)
+	if err != nil {
+		return fmt.Errorf("failed to convert VolumePlacement: %v", err)
+	}
+
+	client := p.dynamicClient.Resource(hybridv1alpha1.VolumePlacementResource).Namespace(vp.Namespace)
+	res := &unstructured.Unstructured{Object: obj}
+
+	err = retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		// The resource version of the previous write is reused, the object is read again only after a conflict.
+		if pl.resourceVersion == "" {
+			current, err := client.Get(ctx, vp.Name, metav1.GetOptions{})
+			if err != nil {
+				if !errors.IsNotFound(err) {
+					return err
+				}
+
+				if current, err = client.Create(ctx, res, metav1.CreateOptions{}); err != nil {
+					return err
+				}
+			}
+
+			pl.resourceVersion = current.GetResourceVersion()
+		}
+
+		res.SetResourceVersion(pl.resourceVersion)
+
+		updated, err := client.UpdateStatus(ctx, res, metav1.UpdateOptions{})
+		if err != nil {
+			pl.resourceVersion = ""
+
+			return err
+		}
+
+		pl.resourceVersion = updated.GetResourceVersion()
+
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update VolumePlacement status: %v", err)
+	}
+
+	return nil
+}
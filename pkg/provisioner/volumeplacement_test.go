@@ -0,0 +1,143 @@
+/*
+Copyright 2023 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package provisioner
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	controller "sigs.k8s.io/sig-storage-lib-external-provisioner/v10/controller"
+
+	hybridv1alpha1 "github.com/sergelogvinov/hybrid-csi-plugin/pkg/apis/hybrid/v1alpha1"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestRecordPlacement(t *testing.T) {
+	tests := []struct {
+		name      string
+		phases    []hybridv1alpha1.VolumePlacementPhase
+		conflicts int
+		// wantUpdates is the number of status updates, the Binding and Releasing phases are written with the next phase
+		wantUpdates int
+		wantPhase   hybridv1alpha1.VolumePlacementPhase
+		wantVolume  string
+	}{
+		{
+			name: "bonded volume",
+			phases: []hybridv1alpha1.VolumePlacementPhase{
+				hybridv1alpha1.VolumePlacementSelecting,
+				hybridv1alpha1.VolumePlacementBinding,
+				hybridv1alpha1.VolumePlacementReleasing,
+				hybridv1alpha1.VolumePlacementBonded,
+			},
+			wantUpdates: 2,
+			wantPhase:   hybridv1alpha1.VolumePlacementBonded,
+			wantVolume:  "pvc-data",
+		},
+		{
+			name:        "failed volume after a conflict",
+			phases:      []hybridv1alpha1.VolumePlacementPhase{hybridv1alpha1.VolumePlacementFailed},
+			conflicts:   1,
+			wantUpdates: 2,
+			wantPhase:   hybridv1alpha1.VolumePlacementFailed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dynamicClient := newTestDynamicClient()
+
+			conflicts := tt.conflicts
+			dynamicClient.PrependReactor("update", "volumeplacements", func(action k8stesting.Action) (bool, runtime.Object, error) {
+				if action.GetSubresource() != "status" || conflicts == 0 {
+					return false, nil, nil
+				}
+
+				conflicts--
+
+				return true, nil, apierrors.NewConflict(hybridv1alpha1.VolumePlacementResource.GroupResource(), "data", fmt.Errorf("object has been modified"))
+			})
+
+			p, _ := newTestProvisioner(t)
+			p.dynamicClient = dynamicClient
+
+			ctx := context.Background()
+			opts := controller.ProvisionOptions{
+				PVC:          newTestClaim("data", "1Gi"),
+				SelectedNode: newTestNode("node-1", nil),
+			}
+
+			pl := &placement{
+				Policy:       &hybridPolicy{Name: "hybrid"},
+				StorageClass: newTestStorageClass("fast", "fast.csi", nil),
+				Candidates:   []candidate{{Name: "fast"}, {Name: "slow", Reason: "backend is in maintenance"}},
+				Method:       methodAnnotation,
+			}
+
+			for _, phase := range tt.phases {
+				if phase == hybridv1alpha1.VolumePlacementBonded {
+					pl.VolumeName = "pvc-data"
+				}
+
+				p.recordPlacement(ctx, opts, pl, phase, "")
+			}
+
+			updates := 0
+
+			for _, action := range dynamicClient.Actions() {
+				if action.Matches("update", "volumeplacements") {
+					updates++
+				}
+			}
+
+			if updates != tt.wantUpdates {
+				t.Errorf("got %d status updates, want %d", updates, tt.wantUpdates)
+			}
+
+			obj, err := dynamicClient.Resource(hybridv1alpha1.VolumePlacementResource).Namespace("default").Get(ctx, "data", metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("failed to get volume placement: %v", err)
+			}
+
+			var vp hybridv1alpha1.VolumePlacement
+			if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, &vp); err != nil {
+				t.Fatalf("failed to convert volume placement: %v", err)
+			}
+
+			if vp.Spec.StorageClassName != "hybrid" || vp.Status.BackendStorageClassName != "fast" || vp.Status.VolumeName != tt.wantVolume {
+				t.Errorf("unexpected volume placement %+v", vp)
+			}
+
+			if vp.Status.Phase != tt.wantPhase || len(vp.Status.Timings) != len(tt.phases) {
+				t.Errorf("got phase %s with %d timings, want %s with %d", vp.Status.Phase, len(vp.Status.Timings), tt.wantPhase, len(tt.phases))
+			}
+
+			if len(vp.Status.RejectedCandidates) != 1 || vp.Status.RejectedCandidates[0].Name != "slow" {
+				t.Errorf("got rejected candidates %+v, want slow", vp.Status.RejectedCandidates)
+			}
+
+			if len(vp.OwnerReferences) != 1 || vp.OwnerReferences[0].UID != opts.PVC.UID {
+				t.Errorf("volume placement is not owned by the claim: %+v", vp.OwnerReferences)
+			}
+		})
+	}
+}
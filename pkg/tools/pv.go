@@ -93,7 +93,7 @@ func PVCCreateOrUpdate(
 }
 
 // PVWaitDelete waits for the specified PersistentVolume to be deleted.
-func PVWaitDelete(ctx context.Context, clientset *clientkubernetes.Clientset, pvName string) error {
+func PVWaitDelete(ctx context.Context, clientset clientkubernetes.Interface, pvName string, deleteTimeout time.Duration) error {
 	_, err := clientset.CoreV1().PersistentVolumes().Get(ctx, pvName, metav1.GetOptions{})
 	if err != nil {
 		return nil //nolint: nilerr
@@ -108,7 +108,7 @@ func PVWaitDelete(ctx context.Context, clientset *clientkubernetes.Clientset, pv
 
 	defer watcher.Stop()
 
-	timeout := time.After(10 * time.Minute)
+	timeout := time.After(deleteTimeout)
 
 	for {
 		select {